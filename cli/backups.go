@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"fmt"
+
+	"OsuCollectionTab/db"
+	"OsuCollectionTab/i18n"
+)
+
+func runCollectionsBackups(args []string) error {
+	return dispatch(progName()+" collections backups", []command{
+		{"list", "List the backups of collection.db, newest first", runBackupsList},
+		{"restore", "Restore collection.db from a backup", runBackupsRestore},
+	}, args)
+}
+
+func runBackupsList(args []string) error {
+	var g globals
+	fs := newFlagSet("collections backups list", &g)
+	if err := g.parse(fs, args); err != nil {
+		return err
+	}
+
+	e, err := g.load()
+	if err != nil {
+		return err
+	}
+	if err := e.requireOsu(); err != nil {
+		return err
+	}
+	backups, err := db.ListBackups(e.collectionDBPath())
+	if err != nil {
+		return err
+	}
+
+	if e.format == "json" {
+		return e.printJSON(backups)
+	}
+	if len(backups) == 0 {
+		e.infof("No backups in %s.\n", db.BackupDir(e.collectionDBPath()))
+		return nil
+	}
+	for _, b := range backups {
+		fmt.Fprint(e.out, i18n.T("%-20s %s %10d bytes\n", b.ID, b.Time.Format("2006-01-02 15:04:05"), b.Size))
+	}
+	return nil
+}
+
+func runBackupsRestore(args []string) error {
+	var g globals
+	fs := newEditFlagSet("collections backups restore", "<id>", &g)
+	dryRun := fs.Bool("dry-run", false, i18n.T("Only show the changes"))
+	yes := fs.Bool("yes", false, i18n.T("Write without asking for confirmation"))
+	if err := parseEditArgs(&g, fs, args, 1, 1); err != nil {
+		return err
+	}
+
+	e, err := g.load()
+	if err != nil {
+		return err
+	}
+	if err := e.requireOsu(); err != nil {
+		return err
+	}
+	l, err := e.lockOsu()
+	if err != nil {
+		return err
+	}
+	defer l.Release()
+
+	target := e.collectionDBPath()
+	backup, err := db.FindBackup(target, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	restored, err := readCollectionFile(backup.Path)
+	if err != nil {
+		return err
+	}
+
+	// A damaged collection.db is the usual reason to restore, so the
+	// preview falls back to listing everything in the backup.
+	current, err := e.loadCollections()
+	if err != nil {
+		e.infof("Cannot read the current collection.db, the whole backup is shown: %v\n", err)
+		current = &db.CollectionDB{}
+	}
+	if err := e.printCollectionDiff(db.DiffCollections(current, restored)); err != nil {
+		return err
+	}
+
+	if *dryRun {
+		return nil
+	}
+	if ok, err := e.confirmWrite(*yes, i18n.T("Restore backup %s to %s?", backup.ID, target)); !ok {
+		return err
+	}
+
+	saved, err := db.SaveCollections(target, restored, e.cfg.BackupRetention)
+	if err != nil {
+		return err
+	}
+	if saved != "" {
+		e.infof("Backup written to %s\n", saved)
+	}
+	e.infof("Restored backup %s to %s\n", backup.ID, target)
+	return nil
+}
@@ -0,0 +1,212 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"OsuCollectionTab/config"
+	"OsuCollectionTab/downloader"
+	"OsuCollectionTab/i18n"
+	"OsuCollectionTab/secret"
+)
+
+// command is a subcommand; run receives the arguments after its name.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
+
+// errUsage marks errors caused by bad invocation; Run exits with status 2.
+var errUsage = errors.New("usage error")
+
+func commands() []command {
+	return []command{
+		{"missing", "List collection beatmaps that are not in osu!.db", runMissing},
+		{"download", "Download missing beatmaps (default command)", runDownload},
+		{"collections", "List, inspect and edit collections in collection.db", runCollections},
+		{"import", "Import collections from other sources", runImport},
+		{"export", "Export collections and their hashes", runExport},
+		{"stats", "Show beatmap and collection counts", runStats},
+		{"config", "Show the effective configuration", runConfig},
+		{"doctor", "Check the installation and configuration", runDoctor},
+	}
+}
+
+// Run executes the command line and returns the process exit status.
+func Run(args []string) int {
+	// The language is needed before any flag set exists so that their help
+	// is translated too; the config file can still change it in load.
+	i18n.SetLang(i18n.Detect(langArg(args), os.Getenv(config.EnvPrefix+"LANG")))
+
+	// Without a subcommand, behave like the original single-purpose tool.
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") && args[0] != "-h" && args[0] != "--help" {
+		args = append([]string{"download"}, args...)
+	}
+
+	err := dispatch(progName(), commands(), args)
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.Is(err, errUsage):
+		fmt.Fprintln(os.Stderr, secret.Redact(err.Error()))
+		return 2
+	default:
+		fmt.Fprint(os.Stderr, i18n.T("Error: %s\n", secret.Redact(err.Error())))
+		return 1
+	}
+}
+
+// langArg returns the value of --lang in args, if any.
+func langArg(args []string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || name != "lang" {
+			continue
+		}
+		if hasValue {
+			return value
+		}
+		if i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
+func progName() string {
+	return filepath.Base(os.Args[0])
+}
+
+// dispatch runs the command named by args[0] from cmds.
+func dispatch(prefix string, cmds []command, args []string) error {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		printCommands(os.Stderr, prefix, cmds)
+		if len(args) == 0 {
+			return i18n.Errorf(errUsage, "missing subcommand")
+		}
+		return flag.ErrHelp
+	}
+
+	for _, c := range cmds {
+		if c.name == args[0] {
+			return c.run(args[1:])
+		}
+	}
+
+	printCommands(os.Stderr, prefix, cmds)
+	return i18n.Errorf(errUsage, "unknown command %q", args[0])
+}
+
+func printCommands(w io.Writer, prefix string, cmds []command) {
+	fmt.Fprint(w, i18n.T("Usage: %s <command> [flags]\n\nCommands:\n", prefix))
+	for _, c := range cmds {
+		fmt.Fprintf(w, "  %-12s %s\n", c.name, i18n.T(c.summary))
+	}
+	fmt.Fprint(w, i18n.T("\nRun '%s <command> -h' for the flags of a command.\n", prefix))
+}
+
+// globals are the flags shared by every command.
+type globals struct {
+	configPath string
+	profile    string
+	format     string
+	lang       string
+	log        logFlags
+
+	fs *flag.FlagSet
+}
+
+// flagKeys maps flags that override a config value to its config key.
+// Only flags given on the command line take part in the layering.
+var flagKeys = map[string]string{
+	"osu":         "osu_path",
+	"songs":       "songs_dir",
+	"proxy":       "proxy",
+	"type":        "download_type",
+	"workers":     "workers",
+	"delay":       "delay",
+	"mirror":      "mirrors",
+	"timeout":     "timeout",
+	"api-timeout": "api_timeout",
+	"token-file":  "token_file",
+	"lang":        "lang",
+}
+
+// newFlagSet creates the flag set of a command with the shared flags registered.
+func newFlagSet(name string, g *globals) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.String("osu", "", i18n.T("osu! installation directory (overrides config)"))
+	fs.String("songs", "", i18n.T("Songs directory (default <osu>/Songs)"))
+	fs.StringVar(&g.configPath, "config", "", i18n.T("Config file to use"))
+	fs.StringVar(&g.profile, "profile", "", i18n.T("Named osu! installation from the config file"))
+	fs.StringVar(&g.format, "format", "text", i18n.T("Output format: text or json"))
+	fs.StringVar(&g.lang, "lang", "", i18n.T("Message language: en or zh (default from config or $LANG)"))
+	g.log.register(fs)
+	g.fs = fs
+	return fs
+}
+
+// addDownloadFlags registers the flags tuning the downloader.
+func addDownloadFlags(fs *flag.FlagSet) {
+	fs.Int("workers", 0, i18n.T("Concurrent download workers (default %d)", config.DefaultWorkers))
+	fs.Float64("delay", 0, i18n.T("Delay between downloads in seconds (default %v)", config.DefaultDelay))
+	fs.String("type", "", i18n.T("Download type: full, novideo or mini"))
+	fs.String("proxy", "", i18n.T("HTTP proxy for downloads and API requests"))
+	fs.Var(new(stringList), "mirror", i18n.T("Download mirror, tried in order (repeatable)"))
+	fs.Duration("timeout", 0, i18n.T("Timeout of a single download (default %v)", downloader.DefaultTimeout))
+	fs.Duration("api-timeout", 0, i18n.T("Timeout of a single API request (default %v)", downloader.DefaultAPITimeout))
+}
+
+// flagValues returns the config overrides given on the command line.
+func (g *globals) flagValues() map[string]string {
+	values := make(map[string]string)
+	g.fs.Visit(func(f *flag.Flag) {
+		if key, ok := flagKeys[f.Name]; ok {
+			values[key] = f.Value.String()
+		}
+	})
+	return values
+}
+
+// parse parses args into fs and validates the shared flags.
+func (g *globals) parse(fs *flag.FlagSet, args []string) error {
+	// Flags may also follow positional arguments, as in
+	// `import mappool pool.csv --name X`; "--" still ends the flags.
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return err
+			}
+			return i18n.Errorf(errUsage, "%v", err)
+		}
+		rest := fs.Args()
+		consumed := len(args) - len(rest)
+		if len(rest) == 0 || consumed > 0 && args[consumed-1] == "--" {
+			positional = append(positional, rest...)
+			break
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+	fs.Parse(append([]string{"--"}, positional...))
+
+	if g.format != "text" && g.format != "json" {
+		return i18n.Errorf(errUsage, "invalid --format %q (want text or json)", g.format)
+	}
+	if _, ok := i18n.ParseLang(g.lang); g.lang != "" && !ok {
+		return i18n.Errorf(errUsage, "invalid --lang %q (want en or zh)", g.lang)
+	}
+	return g.log.setup()
+}
@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFlagsAfterArguments(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []string
+		flag string
+	}{
+		{"flags first", []string{"--name", "X", "pool.csv"}, []string{"pool.csv"}, "X"},
+		{"flags last", []string{"pool.csv", "--name", "X", "--osu", "/osu"}, []string{"pool.csv"}, "X"},
+		{"interspersed", []string{"a", "--name", "X", "b"}, []string{"a", "b"}, "X"},
+		{"double dash", []string{"a", "--", "--name", "X"}, []string{"a", "--name", "X"}, ""},
+		{"no flags", []string{"a", "b"}, []string{"a", "b"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var g globals
+			fs := newFlagSet("test", &g)
+			name := fs.String("name", "", "")
+			if err := g.parse(fs, tt.args); err != nil {
+				t.Fatal(err)
+			}
+			if got := fs.Args(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Args() = %q, want %q", got, tt.want)
+			}
+			if *name != tt.flag {
+				t.Errorf("--name = %q, want %q", *name, tt.flag)
+			}
+		})
+	}
+}
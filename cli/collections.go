@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"OsuCollectionTab/db"
+	"OsuCollectionTab/i18n"
+)
+
+func runCollections(args []string) error {
+	return dispatch(progName()+" collections", []command{
+		{"list", "List collections with their beatmap counts", runCollectionsList},
+		{"inspect", "Show the beatmaps of a collection", runCollectionsInspect},
+		{"create", "Create an empty collection", runCollectionsCreate},
+		{"rename", "Rename a collection", runCollectionsRename},
+		{"delete", "Delete a collection", runCollectionsDelete},
+		{"add", "Add beatmaps by hash or beatmap ID to a collection", runCollectionsAdd},
+		{"remove", "Remove beatmaps by hash or beatmap ID from a collection", runCollectionsRemove},
+		{"diff", "Show the differences between two collection.db files", runCollectionsDiff},
+		{"merge", "Merge other collection.db files into one", runCollectionsMerge},
+		{"union", "Combine the beatmaps of several collections", runCollectionsUnion},
+		{"intersect", "Keep the beatmaps found in every given collection", runCollectionsIntersect},
+		{"subtract", "Remove the beatmaps of later collections from the first", runCollectionsSubtract},
+		{"backups", "List or restore backups of collection.db", runCollectionsBackups},
+		{"share", "Print a share code for a collection", runCollectionsShare},
+		{"import-code", "Add a collection from a share code", runCollectionsImportCode},
+	}, args)
+}
+
+// collectionSummary is one row of `collections list`.
+type collectionSummary struct {
+	Name      string `json:"name"`
+	Beatmaps  int    `json:"beatmaps"`
+	Missing   int    `json:"missing"`
+	Installed int    `json:"installed"`
+}
+
+func runCollectionsList(args []string) error {
+	var g globals
+	fs := newFlagSet("collections list", &g)
+	if err := g.parse(fs, args); err != nil {
+		return err
+	}
+
+	e, collections, osuHashes, err := loadCollectionsAndHashes(&g)
+	if err != nil {
+		return err
+	}
+
+	rows := make([]collectionSummary, 0, len(collections.Collections))
+	for _, c := range collections.Collections {
+		row := collectionSummary{Name: c.Name, Beatmaps: len(c.Hashes)}
+		for _, hash := range c.Hashes {
+			if _, ok := osuHashes[hash]; ok {
+				row.Installed++
+			} else {
+				row.Missing++
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	if e.format == "json" {
+		return e.printJSON(rows)
+	}
+	for _, row := range rows {
+		fmt.Fprint(e.out, i18n.T("%-40s %5d beatmaps, %5d missing\n", row.Name, row.Beatmaps, row.Missing))
+	}
+	return nil
+}
+
+// inspectedBeatmap is one beatmap in `collections inspect`.
+type inspectedBeatmap struct {
+	Hash      string `json:"hash"`
+	Installed bool   `json:"installed"`
+}
+
+// inspectedCollection is the output of `collections inspect`.
+type inspectedCollection struct {
+	Name     string             `json:"name"`
+	Beatmaps []inspectedBeatmap `json:"beatmaps"`
+}
+
+func runCollectionsInspect(args []string) error {
+	var g globals
+	fs := newFlagSet("collections inspect", &g)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, i18n.T("Usage: collections inspect [flags] <name>"))
+		fs.PrintDefaults()
+	}
+	if err := g.parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return i18n.Errorf(errUsage, "expected exactly one collection name")
+	}
+	name := fs.Arg(0)
+
+	e, collections, osuHashes, err := loadCollectionsAndHashes(&g)
+	if err != nil {
+		return err
+	}
+
+	var found []inspectedCollection
+	for _, c := range collections.Collections {
+		if c.Name != name {
+			continue
+		}
+		ic := inspectedCollection{Name: c.Name, Beatmaps: make([]inspectedBeatmap, 0, len(c.Hashes))}
+		for _, hash := range c.Hashes {
+			_, ok := osuHashes[hash]
+			ic.Beatmaps = append(ic.Beatmaps, inspectedBeatmap{Hash: hash, Installed: ok})
+		}
+		found = append(found, ic)
+	}
+	if len(found) == 0 {
+		return i18n.Errorf(nil, "collection %q not found", name)
+	}
+
+	if e.format == "json" {
+		return e.printJSON(found)
+	}
+	for _, ic := range found {
+		fmt.Fprint(e.out, i18n.T("%s (%d beatmaps)\n", ic.Name, len(ic.Beatmaps)))
+		for _, b := range ic.Beatmaps {
+			state := i18n.T("installed")
+			if !b.Installed {
+				state = i18n.T("missing")
+			}
+			fmt.Fprintf(e.out, "  %s  %s\n", b.Hash, state)
+		}
+	}
+	return nil
+}
+
+// loadCollectionsAndHashes loads the environment, collection.db and the
+// installed hashes from osu!.db.
+func loadCollectionsAndHashes(g *globals) (*env, *db.CollectionDB, map[string]struct{}, error) {
+	e, err := g.load()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if err := e.requireOsu(); err != nil {
+		return nil, nil, nil, err
+	}
+
+	collections, err := e.loadCollections()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	osuHashes, err := e.loadOsuHashes()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return e, collections, osuHashes, nil
+}
@@ -0,0 +1,236 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"OsuCollectionTab/db"
+	"OsuCollectionTab/downloader"
+	"OsuCollectionTab/i18n"
+)
+
+var hashPattern = regexp.MustCompile(`^[0-9a-fA-F]{32}$`)
+
+// newEditFlagSet creates the flag set of a collection editing command whose
+// positional arguments are described by usage.
+func newEditFlagSet(name, usage string, g *globals) *flag.FlagSet {
+	fs := newFlagSet(name, g)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, i18n.T("Usage: %s [flags] %s", name, usage))
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseEditArgs parses args and checks that at least min positional
+// arguments, and at most max unless max is negative, were given.
+func parseEditArgs(g *globals, fs *flag.FlagSet, args []string, min, max int) error {
+	if err := g.parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() < min || max >= 0 && fs.NArg() > max {
+		fs.Usage()
+		return i18n.Errorf(errUsage, "wrong number of arguments")
+	}
+	return nil
+}
+
+// editCollections loads collection.db while holding the installation lock,
+// applies edit and writes the result back, keeping a backup of the old file.
+// A fresh installation without collection.db starts from no collections.
+func editCollections(g *globals, edit func(e *env, collections *db.CollectionDB) error) error {
+	e, err := g.load()
+	if err != nil {
+		return err
+	}
+	if err := e.requireOsu(); err != nil {
+		return err
+	}
+	l, err := e.lockOsu()
+	if err != nil {
+		return err
+	}
+	defer l.Release()
+
+	collections := &db.CollectionDB{Version: db.CollectionDBVersion}
+	if _, err := os.Stat(e.collectionDBPath()); !errors.Is(err, os.ErrNotExist) {
+		if collections, err = e.loadCollections(); err != nil {
+			return err
+		}
+	}
+	if err := edit(e, collections); err != nil {
+		return err
+	}
+
+	backup, err := db.SaveCollections(e.collectionDBPath(), collections, e.cfg.BackupRetention)
+	if err != nil {
+		return err
+	}
+	if backup != "" {
+		e.infof("Backup written to %s\n", backup)
+	}
+	return nil
+}
+
+// storeCollection fills the collection name with hashes, creating it when
+// needed. An existing collection is only overwritten when replace is set.
+func storeCollection(collections *db.CollectionDB, name string, hashes []string, replace bool) error {
+	if err := collections.Create(name); err != nil {
+		if !replace || !errors.Is(err, db.ErrCollectionExists) {
+			return err
+		}
+		collections.Find(name).Hashes = nil
+	}
+	_, err := collections.Add(name, hashes...)
+	return err
+}
+
+func runCollectionsCreate(args []string) error {
+	var g globals
+	fs := newEditFlagSet("collections create", "<name>", &g)
+	if err := parseEditArgs(&g, fs, args, 1, 1); err != nil {
+		return err
+	}
+	name := fs.Arg(0)
+
+	return editCollections(&g, func(e *env, collections *db.CollectionDB) error {
+		if err := collections.Create(name); err != nil {
+			return err
+		}
+		e.infof("Created collection %q\n", name)
+		return nil
+	})
+}
+
+func runCollectionsRename(args []string) error {
+	var g globals
+	fs := newEditFlagSet("collections rename", "<name> <new name>", &g)
+	if err := parseEditArgs(&g, fs, args, 2, 2); err != nil {
+		return err
+	}
+	oldName, newName := fs.Arg(0), fs.Arg(1)
+
+	return editCollections(&g, func(e *env, collections *db.CollectionDB) error {
+		if err := collections.Rename(oldName, newName); err != nil {
+			return err
+		}
+		e.infof("Renamed collection %q to %q\n", oldName, newName)
+		return nil
+	})
+}
+
+func runCollectionsDelete(args []string) error {
+	var g globals
+	fs := newEditFlagSet("collections delete", "<name>", &g)
+	if err := parseEditArgs(&g, fs, args, 1, 1); err != nil {
+		return err
+	}
+	name := fs.Arg(0)
+
+	return editCollections(&g, func(e *env, collections *db.CollectionDB) error {
+		if err := collections.Delete(name); err != nil {
+			return err
+		}
+		e.infof("Deleted collection %q\n", name)
+		return nil
+	})
+}
+
+func runCollectionsAdd(args []string) error {
+	var g globals
+	fs := newEditFlagSet("collections add", "<name> <hash|beatmap id>...", &g)
+	if err := parseEditArgs(&g, fs, args, 2, -1); err != nil {
+		return err
+	}
+	name := fs.Arg(0)
+
+	return editCollections(&g, func(e *env, collections *db.CollectionDB) error {
+		hashes, err := e.resolveBeatmaps(fs.Args()[1:])
+		if err != nil {
+			return err
+		}
+		added, err := collections.Add(name, hashes...)
+		if err != nil {
+			return err
+		}
+		e.infof("Added %d beatmaps to %q\n", added, name)
+		return nil
+	})
+}
+
+func runCollectionsRemove(args []string) error {
+	var g globals
+	fs := newEditFlagSet("collections remove", "<name> <hash|beatmap id>...", &g)
+	if err := parseEditArgs(&g, fs, args, 2, -1); err != nil {
+		return err
+	}
+	name := fs.Arg(0)
+
+	return editCollections(&g, func(e *env, collections *db.CollectionDB) error {
+		hashes, err := e.resolveBeatmaps(fs.Args()[1:])
+		if err != nil {
+			return err
+		}
+		removed, err := collections.Remove(name, hashes...)
+		if err != nil {
+			return err
+		}
+		e.infof("Removed %d beatmaps from %q\n", removed, name)
+		return nil
+	})
+}
+
+// resolveBeatmaps turns beatmap hashes and beatmap IDs into hashes. IDs are
+// looked up in osu!.db first and only then through the osu! API.
+func (e *env) resolveBeatmaps(args []string) ([]string, error) {
+	hashes := make([]string, 0, len(args))
+	var byID map[int64]string
+	var dl *downloader.Downloader
+
+	for _, arg := range args {
+		if hashPattern.MatchString(arg) {
+			hashes = append(hashes, strings.ToLower(arg))
+			continue
+		}
+
+		id, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil || id <= 0 {
+			return nil, i18n.Errorf(errUsage, "%q is neither a beatmap hash nor a beatmap ID", arg)
+		}
+
+		if byID == nil {
+			beatmaps, err := db.ReadStable(e.osuDBPath(), db.LoadOsuDBForHash)
+			if err != nil {
+				return nil, i18n.Errorf(nil, "failed to read osu!.db: %v", err)
+			}
+			byID = make(map[int64]string, len(beatmaps))
+			for _, b := range beatmaps {
+				if b.BeatmapID > 0 {
+					byID[int64(b.BeatmapID)] = b.Hash
+				}
+			}
+		}
+		if hash, ok := byID[id]; ok {
+			hashes = append(hashes, hash)
+			continue
+		}
+
+		if dl == nil {
+			var err error
+			if dl, err = e.newDownloader(downloader.TypeFull); err != nil {
+				return nil, err
+			}
+		}
+		hash, err := dl.LookupBeatmapHash(id)
+		if err != nil {
+			return nil, i18n.Errorf(nil, "beatmap %d: %v", id, err)
+		}
+		hashes = append(hashes, hash)
+	}
+	return hashes, nil
+}
@@ -0,0 +1,218 @@
+package cli
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"OsuCollectionTab/config"
+	"OsuCollectionTab/downloader"
+	"OsuCollectionTab/i18n"
+	"OsuCollectionTab/secret"
+	"OsuCollectionTab/utils"
+
+	"gopkg.in/yaml.v2"
+)
+
+func runConfig(args []string) error {
+	return dispatch(progName()+" config", []command{
+		{"show", "Print the effective configuration", runConfigShow},
+		{"init", "Detect the osu! installation and write a config file", runConfigInit},
+		{"check", "Validate the configuration, API token, proxy and mirrors", runConfigCheck},
+	}, args)
+}
+
+func runConfigShow(args []string) error {
+	var g globals
+	fs := newFlagSet("config show", &g)
+	addDownloadFlags(fs)
+	if err := g.parse(fs, args); err != nil {
+		return err
+	}
+
+	e, err := g.load()
+	if err != nil {
+		return err
+	}
+
+	cfg := *e.cfg
+	if cfg.OsuAPIToken != "" {
+		cfg.OsuAPIToken = "***"
+	}
+	if len(cfg.Profiles) > 0 {
+		cfg.Profiles = make(map[string]config.Profile, len(e.cfg.Profiles))
+		for name, p := range e.cfg.Profiles {
+			if p.OsuAPIToken != "" {
+				p.OsuAPIToken = "***"
+			}
+			cfg.Profiles[name] = p
+		}
+	}
+	if cfg.Profile != "" {
+		fmt.Fprint(e.info(), i18n.T("# active profile: %s\n", cfg.Profile))
+	}
+
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	// Masks secrets the explicit replacements above miss, e.g. proxy passwords.
+	data = []byte(secret.Redact(string(data)))
+
+	if e.format == "json" {
+		// Go through YAML so keys and durations match the config file.
+		var view map[string]interface{}
+		if err := yaml.Unmarshal(data, &view); err != nil {
+			return err
+		}
+		return e.printJSON(view)
+	}
+
+	fmt.Fprint(e.out, string(data))
+	return nil
+}
+
+func runConfigInit(args []string) error {
+	var g globals
+	fs := newFlagSet("config init", &g)
+	addDownloadFlags(fs)
+	// The token itself is never a flag, which would leave it in the process
+	// list and the shell history: it comes from the environment or a prompt.
+	fs.String("token-file", "", i18n.T("Read the osu! API token from this file instead of storing it"))
+	outPath := fs.String("o", config.UserConfigPath(), i18n.T("Where to write the config file"))
+	force := fs.Bool("force", false, i18n.T("Overwrite an existing config file"))
+	if err := g.parse(fs, args); err != nil {
+		return err
+	}
+
+	// Start from the defaults only: the wizard must not copy values from an
+	// existing config file into the new one.
+	cfg := config.Defaults()
+	if token := os.Getenv(config.EnvPrefix + "OSU_API_TOKEN"); token != "" {
+		cfg.OsuAPIToken = token
+	}
+	for key, value := range g.flagValues() {
+		if err := cfg.Set(key, value); err != nil {
+			return i18n.Errorf(errUsage, "%s: %v", key, err)
+		}
+	}
+
+	if cfg.OsuPath == "" {
+		if paths := config.DiscoverOsuPaths(); len(paths) > 0 {
+			cfg.OsuPath = paths[0]
+			fmt.Print(i18n.T("Detected osu! at %s\n", cfg.OsuPath))
+		}
+	}
+
+	if utils.IsTerminal(os.Stdin) {
+		var err error
+		if cfg.OsuPath, err = utils.Prompt(i18n.T("osu! installation directory"), cfg.OsuPath); err != nil {
+			return err
+		}
+		if cfg.OsuAPIToken == "" && cfg.TokenFile == "" {
+			fmt.Println(i18n.T("A legacy osu! API key is needed to resolve missing beatmaps:"))
+			fmt.Println(i18n.T("osu! website -> Account Settings -> OAuth -> Legacy API"))
+			if cfg.OsuAPIToken, err = utils.Prompt(i18n.T("osu! API token"), ""); err != nil {
+				return err
+			}
+		}
+		if cfg.DownloadType == "" {
+			if cfg.DownloadType, err = utils.Prompt(i18n.T("Default download type (full/novideo/mini, empty to ask every run)"), ""); err != nil {
+				return err
+			}
+		}
+	}
+
+	if cfg.OsuPath == "" {
+		return i18n.Errorf(nil, "could not detect the osu! installation, pass --osu")
+	}
+	if err := cfg.Validate(); err != nil {
+		return i18n.Errorf(nil, "invalid configuration:\n%v", err)
+	}
+
+	if err := cfg.WriteFile(*outPath, *force); err != nil {
+		return err
+	}
+	fmt.Print(i18n.T("Config written to %s\n", *outPath))
+	if cfg.OsuAPIToken == "" && cfg.TokenFile == "" {
+		fmt.Println(i18n.T("No API token set yet: edit osu_api_token before downloading."))
+	}
+	return nil
+}
+
+func runConfigCheck(args []string) error {
+	var g globals
+	fs := newFlagSet("config check", &g)
+	addDownloadFlags(fs)
+	if err := g.parse(fs, args); err != nil {
+		return err
+	}
+
+	e, err := g.load()
+	if err != nil {
+		return err
+	}
+	cfg := e.cfg
+
+	var checks checkList
+
+	if err := cfg.Validate(); err != nil {
+		for _, problem := range splitErrors(err) {
+			checks.add("config", problem, "")
+		}
+	} else {
+		checks.add("config", nil, i18n.T("valid"))
+	}
+
+	if info, err := os.Stat(e.songsDir()); err != nil {
+		checks.add("songs folder", err, "")
+	} else if !info.IsDir() {
+		checks.add("songs folder", i18n.Errorf(nil, "%s is not a directory", e.songsDir()), "")
+	} else {
+		checks.add("songs folder", nil, e.songsDir())
+	}
+
+	if cfg.Proxy != "" {
+		checks.add("proxy", checkProxy(cfg.Proxy), cfg.Proxy)
+	}
+
+	// The network checks need a downloader, which an invalid proxy prevents.
+	dl, err := e.newDownloader(downloader.TypeFull)
+	if err != nil {
+		checks.add("osu! API token", err, "")
+		return e.printChecks(checks)
+	}
+	checks.add("osu! API token", dl.CheckToken(), i18n.T("accepted"))
+	for _, mirror := range cfg.Mirrors {
+		checks.add("mirror", dl.CheckMirror(mirror), mirror)
+	}
+
+	return e.printChecks(checks)
+}
+
+// checkProxy verifies that the proxy accepts TCP connections.
+func checkProxy(proxy string) error {
+	u, err := downloader.ParseProxy(proxy)
+	if err != nil {
+		return err
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "80")
+	}
+
+	conn, err := net.DialTimeout("tcp", host, 5*time.Second)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// splitErrors returns the individual errors of an errors.Join result.
+func splitErrors(err error) []error {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		return joined.Unwrap()
+	}
+	return []error{err}
+}
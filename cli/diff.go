@@ -0,0 +1,177 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+
+	"OsuCollectionTab/db"
+	"OsuCollectionTab/i18n"
+)
+
+// diffBeatmap is a beatmap in a diff, described from osu!.db when it is
+// installed.
+type diffBeatmap struct {
+	Hash       string `json:"hash"`
+	Artist     string `json:"artist,omitempty"`
+	Title      string `json:"title,omitempty"`
+	Difficulty string `json:"difficulty,omitempty"`
+}
+
+// String returns the hash followed by "Artist - Title [Difficulty]" when
+// the beatmap is known.
+func (b diffBeatmap) String() string {
+	if b.Artist == "" && b.Title == "" {
+		return b.Hash
+	}
+	return fmt.Sprintf("%s  %s - %s [%s]", b.Hash, b.Artist, b.Title, b.Difficulty)
+}
+
+type diffCollection struct {
+	Name     string        `json:"name"`
+	Beatmaps []diffBeatmap `json:"beatmaps"`
+}
+
+type diffRename struct {
+	From    string        `json:"from"`
+	To      string        `json:"to"`
+	Added   []diffBeatmap `json:"added"`
+	Removed []diffBeatmap `json:"removed"`
+}
+
+type diffChange struct {
+	Name    string        `json:"name"`
+	Added   []diffBeatmap `json:"added"`
+	Removed []diffBeatmap `json:"removed"`
+}
+
+// diffReport is the output of `collections diff` and the merge preview.
+type diffReport struct {
+	Added   []diffCollection `json:"added"`
+	Removed []diffCollection `json:"removed"`
+	Renamed []diffRename     `json:"renamed"`
+	Changed []diffChange     `json:"changed"`
+}
+
+func runCollectionsDiff(args []string) error {
+	var g globals
+	fs := newEditFlagSet("collections diff", "<old collection.db> <new collection.db>", &g)
+	if err := parseEditArgs(&g, fs, args, 2, 2); err != nil {
+		return err
+	}
+
+	e, err := g.load()
+	if err != nil {
+		return err
+	}
+	old, err := readCollectionFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	theirs, err := readCollectionFile(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	return e.printCollectionDiff(db.DiffCollections(old, theirs))
+}
+
+// printCollectionDiff prints diff as JSON or text, describing its beatmaps
+// with osu!.db when the installation is available.
+func (e *env) printCollectionDiff(diff *db.CollectionDiff) error {
+	report := newDiffReport(diff, e.beatmapInfo())
+	if e.format == "json" {
+		return e.printJSON(report)
+	}
+	printDiffReport(e.out, report, diff.Empty())
+	return nil
+}
+
+// beatmapInfo returns the beatmaps of osu!.db by hash, or nil when there is
+// no installation or its osu!.db cannot be read.
+func (e *env) beatmapInfo() map[string]db.Difficulty2 {
+	if e.requireOsu() != nil {
+		return nil
+	}
+	beatmaps, err := db.ReadStable(e.osuDBPath(), db.LoadOsuDBForHash)
+	if err != nil {
+		slog.Debug("osu!.db unavailable, showing hashes only", "err", err)
+		return nil
+	}
+
+	byHash := make(map[string]db.Difficulty2, len(beatmaps))
+	for _, b := range beatmaps {
+		if b.Hash != "" {
+			byHash[b.Hash] = b
+		}
+	}
+	return byHash
+}
+
+func newDiffReport(diff *db.CollectionDiff, info map[string]db.Difficulty2) *diffReport {
+	describe := func(hashes []string) []diffBeatmap {
+		beatmaps := make([]diffBeatmap, 0, len(hashes))
+		for _, hash := range hashes {
+			b := diffBeatmap{Hash: hash}
+			if d, ok := info[hash]; ok {
+				b.Artist, b.Title, b.Difficulty = d.Artist, d.Name, d.Difficulty
+			}
+			beatmaps = append(beatmaps, b)
+		}
+		return beatmaps
+	}
+
+	report := &diffReport{
+		Added:   make([]diffCollection, 0, len(diff.Added)),
+		Removed: make([]diffCollection, 0, len(diff.Removed)),
+		Renamed: make([]diffRename, 0, len(diff.Renamed)),
+		Changed: make([]diffChange, 0, len(diff.Changed)),
+	}
+	for _, c := range diff.Added {
+		report.Added = append(report.Added, diffCollection{Name: c.Name, Beatmaps: describe(c.Hashes)})
+	}
+	for _, c := range diff.Removed {
+		report.Removed = append(report.Removed, diffCollection{Name: c.Name, Beatmaps: describe(c.Hashes)})
+	}
+	for _, r := range diff.Renamed {
+		report.Renamed = append(report.Renamed, diffRename{From: r.From, To: r.To, Added: describe(r.Added), Removed: describe(r.Removed)})
+	}
+	for _, c := range diff.Changed {
+		report.Changed = append(report.Changed, diffChange{Name: c.Name, Added: describe(c.Added), Removed: describe(c.Removed)})
+	}
+	return report
+}
+
+// printDiffReport prints added, removed and renamed collections and the
+// beatmaps added to or removed from the others.
+func printDiffReport(w io.Writer, report *diffReport, empty bool) {
+	if empty {
+		fmt.Fprintln(w, i18n.T("No changes."))
+		return
+	}
+
+	printBeatmaps := func(added, removed []diffBeatmap) {
+		for _, b := range added {
+			fmt.Fprintf(w, "    + %s\n", b)
+		}
+		for _, b := range removed {
+			fmt.Fprintf(w, "    - %s\n", b)
+		}
+	}
+
+	for _, c := range report.Added {
+		fmt.Fprint(w, i18n.T("+ %s (%d beatmaps)\n", c.Name, len(c.Beatmaps)))
+	}
+	for _, c := range report.Removed {
+		fmt.Fprint(w, i18n.T("- %s (%d beatmaps)\n", c.Name, len(c.Beatmaps)))
+	}
+	for _, r := range report.Renamed {
+		fmt.Fprint(w, i18n.T("> %s -> %s: %d added, %d removed\n", r.From, r.To, len(r.Added), len(r.Removed)))
+		printBeatmaps(r.Added, r.Removed)
+	}
+	for _, c := range report.Changed {
+		fmt.Fprint(w, i18n.T("~ %s: %d added, %d removed\n", c.Name, len(c.Added), len(c.Removed)))
+		printBeatmaps(c.Added, c.Removed)
+	}
+	fmt.Fprint(w, i18n.T("\n%d collections added, %d removed, %d renamed, %d changed.\n",
+		len(report.Added), len(report.Removed), len(report.Renamed), len(report.Changed)))
+}
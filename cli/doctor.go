@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"OsuCollectionTab/db"
+	"OsuCollectionTab/downloader"
+	"OsuCollectionTab/i18n"
+	"OsuCollectionTab/secret"
+)
+
+// check is the outcome of one doctor check.
+type check struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail"`
+}
+
+// checkList collects the outcomes of doctor and config check.
+type checkList []check
+
+// add records a check that failed with err, or passed with okDetail. Details
+// are redacted, as errors may quote request URLs.
+func (l *checkList) add(name string, err error, okDetail string) {
+	if err != nil {
+		*l = append(*l, check{Name: name, Detail: secret.Redact(err.Error())})
+	} else {
+		*l = append(*l, check{Name: name, OK: true, Detail: secret.Redact(okDetail)})
+	}
+}
+
+func runDoctor(args []string) error {
+	var g globals
+	fs := newFlagSet("doctor", &g)
+	if err := g.parse(fs, args); err != nil {
+		return err
+	}
+
+	e, err := g.load()
+	if err != nil {
+		return err
+	}
+
+	var checks checkList
+
+	checks.add("osu! path", e.requireOsu(), e.cfg.OsuPath)
+
+	beatmaps, err := db.ReadStable(e.osuDBPath(), db.LoadOsuDBForHash)
+	checks.add("osu!.db", err, i18n.T("%d beatmaps", len(beatmaps)))
+
+	collections, err := db.ReadStable(e.collectionDBPath(), db.ReadCollections)
+	if err == nil {
+		checks.add("collection.db", nil, i18n.T("%d collections", len(collections.Collections)))
+	} else {
+		checks.add("collection.db", err, "")
+	}
+
+	checks.add("Songs folder", checkWritable(e.songsDir()), e.songsDir())
+
+	var tokenErr error
+	if e.cfg.OsuAPIToken == "" {
+		tokenErr = i18n.Errorf(downloader.ErrNoToken, "no osu! API token configured")
+	}
+	checks.add("osu! API token", tokenErr, i18n.T("configured"))
+
+	if _, err := downloader.ParseDownloadType(e.cfg.DownloadType); e.cfg.DownloadType != "" && err != nil {
+		checks.add("download type", err, "")
+	}
+
+	return e.printChecks(checks)
+}
+
+// printChecks prints the outcome of every check and fails if any did.
+func (e *env) printChecks(checks []check) error {
+	failed := 0
+	for _, c := range checks {
+		if !c.OK {
+			failed++
+		}
+	}
+
+	if e.format == "json" {
+		if err := e.printJSON(checks); err != nil {
+			return err
+		}
+	} else {
+		for _, c := range checks {
+			status := "OK  "
+			if !c.OK {
+				status = "FAIL"
+			}
+			fmt.Fprintf(e.out, "[%s] %-16s %s\n", status, i18n.T(c.Name), c.Detail)
+		}
+	}
+
+	if failed > 0 {
+		return i18n.Errorf(nil, "%d of %d checks failed", failed, len(checks))
+	}
+	return nil
+}
+
+// checkWritable verifies that files can be created in dir.
+func checkWritable(dir string) error {
+	f, err := os.CreateTemp(dir, ".doctor-*")
+	if err != nil {
+		return err
+	}
+	name := f.Name()
+	f.Close()
+	return os.Remove(name)
+}
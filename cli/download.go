@@ -0,0 +1,270 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"OsuCollectionTab/downloader"
+	"OsuCollectionTab/i18n"
+	"OsuCollectionTab/utils"
+)
+
+func runDownload(args []string) error {
+	var g globals
+	fs := newFlagSet("download", &g)
+	fromProfile := fs.String("from-profile", "", i18n.T("Sync the collections of this profile into the current one"))
+	fromFile := fs.String("from", "", i18n.T("Sync the collections of this collection.db or .osdb file, or download the beatmaps of a list of links, IDs and hashes"))
+	bareIDs := fs.String("ids", "set", i18n.T("What bare numbers in a --from list are: set or beatmap IDs"))
+	addDownloadFlags(fs)
+	reportPath := fs.String("report", "unresolved.txt", i18n.T("File to write unresolved hashes to"))
+	dryRun := fs.Bool("dry-run", false, i18n.T("Resolve missing beatmaps and print the download plan without downloading"))
+	planOut := fs.String("plan-out", "", i18n.T("Write the download plan as JSON to this file"))
+	planIn := fs.String("plan", "", i18n.T("Download the sets of a plan file instead of reading the databases"))
+	if err := g.parse(fs, args); err != nil {
+		return err
+	}
+	if *fromFile != "" && *fromProfile != "" {
+		return i18n.Errorf(errUsage, "--from and --from-profile cannot be used together")
+	}
+	if *bareIDs != "set" && *bareIDs != "beatmap" {
+		return i18n.Errorf(errUsage, "invalid --ids %q (want set or beatmap)", *bareIDs)
+	}
+
+	e, err := g.load()
+	if err != nil {
+		return err
+	}
+	if err := e.requireOsu(); err != nil {
+		return err
+	}
+	if err := e.cfg.Validate(); err != nil {
+		return i18n.Errorf(nil, "invalid configuration (run 'config check' for details):\n%v", err)
+	}
+	e.infof("Found your osu! at %s.\n\n", e.cfg.OsuPath)
+
+	if !*dryRun {
+		l, err := e.lockOsu()
+		if err != nil {
+			return err
+		}
+		defer l.Release()
+	}
+
+	if *planIn != "" {
+		plan, err := downloader.LoadPlan(*planIn)
+		if err != nil {
+			return i18n.Errorf(nil, "failed to load plan: %v", err)
+		}
+
+		dl, err := e.newDownloader(plan.Type)
+		if err != nil {
+			return err
+		}
+		dl.SetMirrors(plan.Mirrors)
+		dl.WantPlan(plan)
+		e.infof("Downloading %d beatmapsets from %s...\n\n", len(plan.Sets), *planIn)
+		if err := dl.DownloadAll(plan.SetIDs()); err != nil {
+			return i18n.Errorf(nil, "error downloading beatmaps: %v", err)
+		}
+		e.infof("Plan finished.\n")
+		return nil
+	}
+
+	if *fromFile != "" && !isCollectionFile(*fromFile) {
+		return e.downloadList(*fromFile, *bareIDs == "beatmap", *dryRun, *planOut)
+	}
+
+	// 读取数据库
+	e.infof("Starting to load beatmaps from your osu!.db\n")
+	osuHashes, err := e.loadOsuHashes()
+	if err != nil {
+		return err
+	}
+	e.infof("Loaded %d beatmaps from osu!.db\n", len(osuHashes))
+
+	collections, knownSetIDs, err := e.loadSourceCollections(*fromProfile, *fromFile)
+	if err != nil {
+		return err
+	}
+	if *fromFile != "" {
+		e.infof("Loaded %d beatmaps from %s\n", len(collections.AllHashes()), *fromFile)
+	} else {
+		e.infof("Loaded %d beatmaps from collection.db\n", len(collections.AllHashes()))
+	}
+
+	missing := missingHashes(osuHashes, collections)
+	if len(missing) == 0 {
+		e.infof("No missing beatmaps found!\n")
+		return nil
+	}
+	e.infof("Found %d missing beatmaps.\n", len(missing))
+
+	// 先选择下载类型，解析和下载随后以流水线方式同时进行
+	downloadType, err := chooseDownloadType(e.cfg.DownloadType)
+	if err != nil {
+		return err
+	}
+	dl, err := e.newDownloader(downloadType)
+	if err != nil {
+		return err
+	}
+	if len(knownSetIDs) > 0 {
+		dl.SetKnownSetIDs(knownSetIDs)
+		e.infof("%d beatmaps have their set ID in %s and are not looked up.\n", len(knownSetIDs), *fromFile)
+	}
+
+	if *dryRun || *planOut != "" {
+		e.infof("Resolving beatmapsets...\n\n")
+		plan := dl.BuildPlan(dl.ResolveAll(missing), collectionsByHash(collections))
+		if *dryRun {
+			if e.format == "json" {
+				if err := e.printJSON(plan); err != nil {
+					return err
+				}
+			} else {
+				printPlan(e.out, plan)
+			}
+		}
+		if *planOut != "" {
+			if err := downloader.SavePlan(*planOut, plan); err != nil {
+				return i18n.Errorf(nil, "failed to write plan: %v", err)
+			}
+			e.infof("Plan written to %s\n", *planOut)
+		}
+		e.reportUnresolved(dl, collections, *reportPath)
+		if *dryRun {
+			return nil
+		}
+
+		if err := dl.DownloadAll(plan.SetIDs()); err != nil {
+			return i18n.Errorf(nil, "error downloading beatmaps: %v", err)
+		}
+		e.infof("All missing beatmaps downloaded successfully!\n")
+		return nil
+	}
+
+	e.infof("Starting the downloader...\n\n")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stats, err := dl.DownloadStream(dl.ResolveSetIDs(ctx, missing))
+	if err != nil {
+		return i18n.Errorf(nil, "error downloading beatmaps: %v", err)
+	}
+
+	e.infof("\nThe %d missing beatmaps in your collection are from %d beatmapsets.\n", len(missing), stats.Sets)
+	e.reportUnresolved(dl, collections, *reportPath)
+	if stats.Failed > 0 {
+		return i18n.Errorf(nil, "%d of %d beatmapsets failed to download", stats.Failed, stats.Sets)
+	}
+
+	e.infof("All missing beatmaps downloaded successfully!\n")
+	return nil
+}
+
+// newDownloader creates a downloader tuned by the configuration.
+func (e *env) newDownloader(downloadType downloader.DownloadType) (*downloader.Downloader, error) {
+	dl, err := downloader.NewDownloader(
+		e.songsDir(),
+		e.cfg.Proxy,
+		e.cfg.Workers,
+		time.Duration(e.cfg.Delay*float64(time.Second)),
+		e.cfg.OsuAPIToken,
+		downloadType,
+	)
+	if err != nil {
+		return nil, err
+	}
+	dl.SetMirrors(e.cfg.Mirrors)
+	dl.SetTimeouts(e.cfg.Timeout, e.cfg.APITimeout)
+	dl.SetOsuCollectorURL(e.cfg.OsuCollectorURL)
+	return dl, nil
+}
+
+// chooseDownloadType uses the configured type (from --type, the environment
+// or a config file) and only prompts when nothing was configured and stdin is
+// a terminal.
+func chooseDownloadType(configured string) (downloader.DownloadType, error) {
+	if configured != "" {
+		return downloader.ParseDownloadType(configured)
+	}
+
+	if !utils.IsTerminal(os.Stdin) {
+		return "", i18n.Errorf(nil, "no download type configured: pass --type full|novideo|mini or set download_type in config.yaml")
+	}
+
+	choice, err := utils.PromptDownloadType()
+	if err != nil {
+		return "", err
+	}
+	return downloader.ParseDownloadType(choice)
+}
+
+func printPlan(w io.Writer, plan *downloader.Plan) {
+	fmt.Fprint(w, i18n.T("Mirrors: %s\nType:    %s\n\n", strings.Join(plan.Mirrors, ", "), plan.Type))
+	for _, set := range plan.Sets {
+		fmt.Fprint(w, i18n.T("Set %d\n", set.SetID))
+		for _, h := range set.Hashes {
+			fmt.Fprintf(w, "  %s  %s\n", h.Hash, strings.Join(h.Collections, ", "))
+		}
+	}
+	for _, h := range plan.Unresolved {
+		fmt.Fprint(w, i18n.T("Unresolved %s (%s)  %s\n", h.Hash, h.Reason, strings.Join(h.Collections, ", ")))
+	}
+	fmt.Fprint(w, i18n.T("\n%d beatmapsets would be downloaded, %d beatmaps are unresolved.\n", len(plan.Sets), len(plan.Unresolved)))
+}
+
+// downloadMissingSets downloads the sets of the hashes in setIDs that are not
+// in osu!.db, holding the installation lock. Sets already in the Songs folder
+// are skipped by the downloader when they contain the wanted difficulties.
+func (e *env) downloadMissingSets(setIDs map[string]int64) error {
+	if err := e.requireOsu(); err != nil {
+		return err
+	}
+	if err := e.cfg.Validate(); err != nil {
+		return i18n.Errorf(nil, "invalid configuration (run 'config check' for details):\n%v", err)
+	}
+	l, err := e.lockOsu()
+	if err != nil {
+		return err
+	}
+	defer l.Release()
+
+	osuHashes, err := e.loadOsuHashes()
+	if err != nil {
+		return err
+	}
+	missing := make(map[string]int64)
+	sets := make(map[int64]struct{})
+	for hash, setID := range setIDs {
+		if _, ok := osuHashes[hash]; !ok {
+			missing[hash] = setID
+			sets[setID] = struct{}{}
+		}
+	}
+	if len(sets) == 0 {
+		e.infof("No missing beatmaps found!\n")
+		return nil
+	}
+
+	downloadType, err := chooseDownloadType(e.cfg.DownloadType)
+	if err != nil {
+		return err
+	}
+	e.infof("Downloading %d beatmapsets...\n\n", len(sets))
+	dl, err := e.newDownloader(downloadType)
+	if err != nil {
+		return err
+	}
+	for hash, setID := range missing {
+		dl.Want(setID, hash)
+	}
+	if err := dl.DownloadAll(sets); err != nil {
+		return i18n.Errorf(nil, "error downloading beatmaps: %v", err)
+	}
+	e.infof("All missing beatmaps downloaded successfully!\n")
+	return nil
+}
@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"OsuCollectionTab/db"
+	"OsuCollectionTab/downloader"
+	"OsuCollectionTab/i18n"
+)
+
+// isCollectionFile reports whether --from names collections (a collection.db
+// or .osdb file) rather than a download list.
+func isCollectionFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".db" || ext == ".osdb"
+}
+
+// downloadList downloads the sets named in the list file at path. Entries
+// are resolved with osu!.db first and the osu! API otherwise, and sets that
+// osu!.db already has are skipped. With planOut the sets are also written as
+// a plan, which lists the wanted hashes where the list named them.
+func (e *env) downloadList(path string, bareBeatmaps, dryRun bool, planOut string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return i18n.Errorf(nil, "failed to read %s: %v", path, err)
+	}
+	entries, invalid, err := downloader.ParseList(f, bareBeatmaps)
+	f.Close()
+	if err != nil {
+		return i18n.Errorf(nil, "failed to read %s: %v", path, err)
+	}
+	for _, line := range invalid {
+		e.infof("Ignoring unrecognized line: %s\n", line)
+	}
+	if len(entries) == 0 {
+		return i18n.Errorf(nil, "no beatmaps found in %s", path)
+	}
+
+	beatmaps, err := db.ReadStable(e.osuDBPath(), db.LoadOsuDBForHash)
+	if err != nil {
+		return i18n.Errorf(nil, "failed to read osu!.db: %v", err)
+	}
+	installedSets := make(map[int64]bool)
+	setByID := make(map[int64]int64)
+	setByHash := make(map[string]int64)
+	for _, b := range beatmaps {
+		installedSets[int64(b.BeatmapsetID)] = true
+		setByID[int64(b.BeatmapID)] = int64(b.BeatmapsetID)
+		setByHash[b.Hash] = int64(b.BeatmapsetID)
+	}
+
+	downloadType, err := chooseDownloadType(e.cfg.DownloadType)
+	if err != nil {
+		return err
+	}
+	dl, err := e.newDownloader(downloadType)
+	if err != nil {
+		return err
+	}
+
+	sets := make(map[int64][]downloader.PlannedHash)
+	installed, unresolved := 0, 0
+	for _, entry := range entries {
+		var setID int64
+		var hash string
+		var err error
+		switch entry.Kind {
+		case downloader.EntrySet:
+			setID = entry.ID
+		case downloader.EntryBeatmap:
+			var ok bool
+			if setID, ok = setByID[entry.ID]; !ok {
+				var ref downloader.BeatmapRef
+				ref, err = dl.LookupBeatmap(entry.ID)
+				setID, hash = ref.SetID, ref.Hash
+			}
+		case downloader.EntryHash:
+			var ok bool
+			hash = entry.Hash
+			if setID, ok = setByHash[entry.Hash]; !ok {
+				setID, err = dl.LookupSetID(entry.Hash)
+			}
+		}
+		if err != nil {
+			e.infof("Could not resolve %s (line %d): %v\n", entry.Text, entry.Line, err)
+			unresolved++
+			continue
+		}
+
+		if installedSets[setID] {
+			installed++
+			continue
+		}
+		hashes := sets[setID]
+		if hash != "" && !slices.ContainsFunc(hashes, func(h downloader.PlannedHash) bool { return h.Hash == hash }) {
+			dl.Want(setID, hash)
+			hashes = append(hashes, downloader.PlannedHash{Hash: hash})
+		}
+		sets[setID] = hashes
+	}
+	e.infof("%d entries: %d beatmapsets to download, %d already installed, %d unresolved.\n", len(entries), len(sets), installed, unresolved)
+
+	plan := &downloader.Plan{Mirrors: dl.Mirrors(), Type: dl.DownloadType()}
+	for setID, hashes := range sets {
+		plan.Sets = append(plan.Sets, downloader.PlannedSet{SetID: setID, Hashes: hashes})
+	}
+	sort.Slice(plan.Sets, func(i, j int) bool { return plan.Sets[i].SetID < plan.Sets[j].SetID })
+	if planOut != "" {
+		if err := downloader.SavePlan(planOut, plan); err != nil {
+			return i18n.Errorf(nil, "failed to write plan: %v", err)
+		}
+		e.infof("Plan written to %s\n", planOut)
+	}
+
+	if dryRun {
+		ids := make([]int64, 0, len(plan.Sets))
+		for _, set := range plan.Sets {
+			ids = append(ids, set.SetID)
+		}
+		if e.format == "json" {
+			return e.printJSON(ids)
+		}
+		for _, id := range ids {
+			fmt.Fprint(e.out, i18n.T("Set %d\n", id))
+		}
+		return nil
+	}
+
+	if len(sets) > 0 {
+		e.infof("Downloading %d beatmapsets...\n\n", len(sets))
+		if err := dl.DownloadAll(plan.SetIDs()); err != nil {
+			return i18n.Errorf(nil, "error downloading beatmaps: %v", err)
+		}
+	}
+	if unresolved > 0 {
+		return i18n.Errorf(nil, "%d entries could not be resolved", unresolved)
+	}
+	e.infof("All missing beatmaps downloaded successfully!\n")
+	return nil
+}
@@ -0,0 +1,183 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"OsuCollectionTab/config"
+	"OsuCollectionTab/db"
+	"OsuCollectionTab/i18n"
+	"OsuCollectionTab/lock"
+	"OsuCollectionTab/utils"
+)
+
+// env is the loaded configuration a command operates on.
+type env struct {
+	cfg    *config.Config
+	format string
+	out    io.Writer
+}
+
+// load reads the layered configuration, with the flags given on the command
+// line taking precedence.
+func (g *globals) load() (*env, error) {
+	cfg, err := config.Load(config.LoadOptions{
+		ConfigFile: g.configPath,
+		Values:     g.flagValues(),
+		Profile:    g.profile,
+	})
+	if err != nil {
+		return nil, i18n.Errorf(nil, "failed to load config: %v", err)
+	}
+	// --lang is part of the flag values, so cfg.Lang already has the
+	// highest-priority choice.
+	if lang, ok := i18n.ParseLang(cfg.Lang); ok {
+		i18n.SetLang(lang)
+	}
+
+	return &env{cfg: cfg, format: g.format, out: os.Stdout}, nil
+}
+
+// requireOsu fails unless the configured osu! directory exists.
+func (e *env) requireOsu() error {
+	if e.cfg.OsuPath == "" || !utils.PathExists(e.cfg.OsuPath) {
+		if e.cfg.OsuPath == "" {
+			return i18n.Errorf(nil, "could not find the osu! installation: set osu_path in config.yaml or pass --osu")
+		}
+		return i18n.Errorf(nil, "could not find osu! path: %s", e.cfg.OsuPath)
+	}
+	return nil
+}
+
+// lockOsu takes the single-instance lock of the osu! installation. Commands
+// that write to the installation or its Songs folder hold it until they return.
+// It also warns when osu! itself is running, as it rewrites its databases.
+func (e *env) lockOsu() (*lock.Lock, error) {
+	l, err := lock.Acquire(e.cfg.OsuPath)
+	if err != nil {
+		return nil, err
+	}
+	if running, err := utils.OsuRunning(); err == nil && running {
+		fmt.Fprint(os.Stderr, i18n.T("Warning: osu! is running and may overwrite changes to its databases when it exits.\n"))
+	}
+	return l, nil
+}
+
+// osuFile returns the path of a file in the osu! directory, matching its
+// name case-insensitively.
+func (e *env) osuFile(name string) string {
+	path, _ := utils.FindFold(e.cfg.OsuPath, name)
+	return path
+}
+
+func (e *env) osuDBPath() string {
+	return e.osuFile("osu!.db")
+}
+
+func (e *env) collectionDBPath() string {
+	return e.osuFile("collection.db")
+}
+
+func (e *env) songsDir() string {
+	return e.cfg.SongsPath()
+}
+
+// info returns where progress messages go: stderr in JSON mode so that
+// stdout stays machine-readable.
+func (e *env) info() io.Writer {
+	if e.format == "json" {
+		return os.Stderr
+	}
+	return e.out
+}
+
+// infof prints a translated progress message.
+func (e *env) infof(format string, args ...any) {
+	fmt.Fprint(e.info(), i18n.T(format, args...))
+}
+
+func (e *env) printJSON(v any) error {
+	enc := json.NewEncoder(e.out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// loadOsuHashes returns the hashes of every beatmap in osu!.db.
+func (e *env) loadOsuHashes() (map[string]struct{}, error) {
+	beatmaps, err := db.ReadStable(e.osuDBPath(), db.LoadOsuDBForHash)
+	if err != nil {
+		return nil, i18n.Errorf(nil, "failed to read osu!.db: %v", err)
+	}
+
+	osuHashes := make(map[string]struct{}, len(beatmaps)) // 使用空结构体节省内存
+	for _, beatmap := range beatmaps {
+		if beatmap.Hash != "" { // 确保哈希不为空
+			osuHashes[beatmap.Hash] = struct{}{}
+		}
+	}
+	return osuHashes, nil
+}
+
+func (e *env) loadCollections() (*db.CollectionDB, error) {
+	collections, err := db.ReadStable(e.collectionDBPath(), db.ReadCollections)
+	if err != nil {
+		return nil, i18n.Errorf(nil, "failed to read collection.db: %v", err)
+	}
+	return collections, nil
+}
+
+// loadSourceCollections reads the collections to sync from: those of the
+// file fromFile, of the profile named fromProfile, or the current
+// installation's when both are empty. The set IDs stored in a .osdb file are
+// returned by hash.
+func (e *env) loadSourceCollections(fromProfile, fromFile string) (*db.CollectionDB, map[string]int64, error) {
+	switch {
+	case isOsdbFile(fromFile):
+		osdb, err := readOsdbFile(fromFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		return osdb.CollectionDB(), osdb.SetIDs(), nil
+	case fromFile != "":
+		collections, err := readCollectionFile(fromFile)
+		return collections, nil, err
+	case fromProfile == "":
+		collections, err := e.loadCollections()
+		return collections, nil, err
+	}
+
+	src, err := e.cfg.ForProfile(fromProfile)
+	if err != nil {
+		return nil, nil, err
+	}
+	path, _ := utils.FindFold(src.OsuPath, "collection.db")
+	collections, err := db.ReadStable(path, db.ReadCollections)
+	if err != nil {
+		return nil, nil, i18n.Errorf(nil, "failed to read collection.db of profile %s: %v", fromProfile, err)
+	}
+	return collections, nil, nil
+}
+
+// missingHashes returns the collection hashes that are not installed.
+func missingHashes(osuHashes map[string]struct{}, collections *db.CollectionDB) map[string]struct{} {
+	missing := make(map[string]struct{})
+	for hash := range collections.AllHashes() {
+		if _, exists := osuHashes[hash]; !exists {
+			missing[hash] = struct{}{}
+		}
+	}
+	return missing
+}
+
+// collectionsByHash maps each hash to the names of the collections containing it.
+func collectionsByHash(collections *db.CollectionDB) map[string][]string {
+	names := make(map[string][]string)
+	for _, c := range collections.Collections {
+		for _, hash := range c.Hashes {
+			names[hash] = append(names[hash], c.Name)
+		}
+	}
+	return names
+}
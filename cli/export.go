@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"OsuCollectionTab/db"
+	"OsuCollectionTab/i18n"
+)
+
+// stringList is a repeatable string flag.
+type stringList []string
+
+func (l *stringList) String() string     { return strings.Join(*l, ",") }
+func (l *stringList) Set(v string) error { *l = append(*l, v); return nil }
+
+func runExport(args []string) error {
+	var g globals
+	fs := newFlagSet("export", &g)
+	var only stringList
+	fs.Var(&only, "collection", i18n.T("Only export this collection (repeatable)"))
+	outPath := fs.String("o", "", i18n.T("Write to this file instead of stdout; a .osdb file is written in Collection Manager format"))
+	if err := g.parse(fs, args); err != nil {
+		return err
+	}
+
+	e, err := g.load()
+	if err != nil {
+		return err
+	}
+	if err := e.requireOsu(); err != nil {
+		return err
+	}
+	collections, err := e.loadCollections()
+	if err != nil {
+		return err
+	}
+
+	selected := collections.Collections
+	if len(only) > 0 {
+		selected = nil
+		for _, name := range only {
+			n := len(selected)
+			for _, c := range collections.Collections {
+				if c.Name == name {
+					selected = append(selected, c)
+				}
+			}
+			if len(selected) == n {
+				return i18n.Errorf(nil, "collection %q not found", name)
+			}
+		}
+	}
+
+	if isOsdbFile(*outPath) {
+		return e.exportOsdb(*outPath, selected)
+	}
+
+	var w io.Writer = e.out
+	if *outPath != "" {
+		f, err := os.Create(*outPath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	bw := bufio.NewWriter(w)
+	if err := writeExport(bw, e.format, selected); err != nil {
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	if *outPath != "" {
+		e.infof("Exported %d collections to %s\n", len(selected), *outPath)
+	}
+	return nil
+}
+
+// exportOsdb writes collections as a Collection Manager .osdb file. Beatmaps
+// installed in osu! are stored with their IDs and metadata.
+func (e *env) exportOsdb(path string, collections []db.Collection) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = db.WriteOsdb(f, db.NewOsdb(collections, e.beatmapInfo()))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return i18n.Errorf(nil, "failed to write %s: %v", path, err)
+	}
+	e.infof("Exported %d collections to %s\n", len(collections), path)
+	return nil
+}
+
+// writeExport writes collections as JSON or as tab-separated name/hash lines.
+func writeExport(w io.Writer, format string, collections []db.Collection) error {
+	if format == "json" {
+		type exported struct {
+			Name   string   `json:"name"`
+			Hashes []string `json:"hashes"`
+		}
+		out := make([]exported, 0, len(collections))
+		for _, c := range collections {
+			out = append(out, exported{Name: c.Name, Hashes: c.Hashes})
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+
+	for _, c := range collections {
+		for _, hash := range c.Hashes {
+			if _, err := fmt.Fprintf(w, "%s\t%s\n", c.Name, hash); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
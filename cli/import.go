@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"OsuCollectionTab/db"
+	"OsuCollectionTab/downloader"
+	"OsuCollectionTab/i18n"
+)
+
+func runImport(args []string) error {
+	return dispatch(progName()+" import", []command{
+		{"osucollector", "Import a collection from osu!collector", runImportOsuCollector},
+		{"mappool", "Create collections from a tournament mappool CSV", runImportMappool},
+	}, args)
+}
+
+// collectorURLPattern finds the collection ID in an osu!collector link such
+// as https://osucollector.com/collections/123/name.
+var collectorURLPattern = regexp.MustCompile(`/collections/(\d+)`)
+
+// parseCollectorID accepts an osu!collector collection ID or link.
+func parseCollectorID(arg string) (int64, error) {
+	if m := collectorURLPattern.FindStringSubmatch(arg); m != nil {
+		arg = m[1]
+	}
+	id, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, i18n.Errorf(errUsage, "%q is neither an osu!collector collection ID nor a link to one", arg)
+	}
+	return id, nil
+}
+
+func runImportOsuCollector(args []string) error {
+	var g globals
+	fs := newEditFlagSet("import osucollector", "<id|url>", &g)
+	addDownloadFlags(fs)
+	save := fs.Bool("save", false, i18n.T("Write the beatmaps into collection.db"))
+	name := fs.String("name", "", i18n.T("Name of the collection in collection.db (default: the osu!collector name)"))
+	replace := fs.Bool("replace", false, i18n.T("Overwrite the collection if it exists"))
+	download := fs.Bool("download", false, i18n.T("Download the beatmapsets that are not installed"))
+	if err := parseEditArgs(&g, fs, args, 1, 1); err != nil {
+		return err
+	}
+	id, err := parseCollectorID(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	e, err := g.load()
+	if err != nil {
+		return err
+	}
+	dl, err := e.newDownloader(downloader.TypeFull)
+	if err != nil {
+		return err
+	}
+	collection, err := dl.FetchOsuCollector(id)
+	if err != nil {
+		return i18n.Errorf(nil, "failed to fetch osu!collector collection %d: %v", id, err)
+	}
+	hashes := collection.Hashes()
+
+	if !*save && !*download {
+		if e.format == "json" {
+			return e.printJSON(collection)
+		}
+		fmt.Fprint(e.out, i18n.T("%s (%d beatmaps)\n", collection.Name, len(hashes)))
+		for _, set := range collection.Beatmapsets {
+			fmt.Fprint(e.out, i18n.T("Set %d\n", set.ID))
+			for _, b := range set.Beatmaps {
+				fmt.Fprintf(e.out, "  %s  %d\n", strings.ToLower(b.Checksum), b.ID)
+			}
+		}
+		return nil
+	}
+	e.infof("Fetched %q from osu!collector: %d beatmaps in %d beatmapsets\n", collection.Name, len(hashes), len(collection.Beatmapsets))
+
+	if *save {
+		target := *name
+		if target == "" {
+			target = collection.Name
+		}
+		err := editCollections(&g, func(e *env, collections *db.CollectionDB) error {
+			if err := storeCollection(collections, target, hashes, *replace); err != nil {
+				return err
+			}
+			e.infof("Wrote %d beatmaps to collection %q\n", len(hashes), target)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if *download {
+		return e.downloadMissingSets(collection.SetIDs())
+	}
+	return nil
+}
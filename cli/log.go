@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"flag"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"OsuCollectionTab/i18n"
+	"OsuCollectionTab/secret"
+)
+
+// logFlags configure the slog default logger.
+type logFlags struct {
+	level  string
+	format string
+	file   string
+}
+
+func (l *logFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&l.level, "log-level", "info", i18n.T("Log level: debug, info, warn or error"))
+	fs.StringVar(&l.format, "log-format", "text", i18n.T("Log format: text or json"))
+	fs.StringVar(&l.file, "log-file", "", i18n.T("Append logs to this file instead of stderr"))
+}
+
+// setup installs the default logger. Every logged string is passed through
+// secret.Redact, so tokens never reach a log.
+func (l *logFlags) setup() error {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(l.level)); err != nil {
+		return i18n.Errorf(errUsage, "invalid --log-level %q", l.level)
+	}
+
+	var w io.Writer = os.Stderr
+	if l.file != "" {
+		f, err := os.OpenFile(l.file, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			return i18n.Errorf(nil, "failed to open log file: %v", err)
+		}
+		// Left open for the lifetime of the process.
+		w = f
+	}
+
+	opts := &slog.HandlerOptions{
+		Level: level,
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			switch a.Value.Kind() {
+			case slog.KindString:
+				a.Value = slog.StringValue(secret.Redact(a.Value.String()))
+			case slog.KindAny:
+				if err, ok := a.Value.Any().(error); ok {
+					a.Value = slog.StringValue(secret.Redact(err.Error()))
+				}
+			}
+			return a
+		},
+	}
+
+	var handler slog.Handler
+	switch strings.ToLower(l.format) {
+	case "text":
+		handler = slog.NewTextHandler(w, opts)
+	case "json":
+		handler = slog.NewJSONHandler(w, opts)
+	default:
+		return i18n.Errorf(errUsage, "invalid --log-format %q (want text or json)", l.format)
+	}
+
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
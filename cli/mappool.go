@@ -0,0 +1,242 @@
+package cli
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"OsuCollectionTab/db"
+	"OsuCollectionTab/downloader"
+	"OsuCollectionTab/i18n"
+)
+
+// poolSlot is one row of a mappool sheet.
+type poolSlot struct {
+	Slot      string `json:"slot"`
+	BeatmapID int64  `json:"beatmap_id"`
+	Hash      string `json:"hash"`
+	SetID     int64  `json:"set_id"`
+}
+
+// bracket returns the mod bracket of the slot: its leading letters, as in
+// NM for NM1 or TB for TB.
+func (s poolSlot) bracket() string {
+	end := strings.IndexFunc(s.Slot, func(r rune) bool { return r < 'A' || r > 'Z' })
+	if end == -1 {
+		end = len(s.Slot)
+	}
+	if end == 0 {
+		return s.Slot
+	}
+	return s.Slot[:end]
+}
+
+// invalidPoolRow is a row of a mappool sheet whose beatmap cell could not be
+// read.
+type invalidPoolRow struct {
+	Line   int
+	Slot   string
+	Cell   string
+	Reason string
+}
+
+var (
+	// A difficulty link such as osu.ppy.sh/beatmapsets/1#osu/2 or osu.ppy.sh/b/2.
+	poolModeURLPattern    = regexp.MustCompile(`#[a-z]+/(\d+)`)
+	poolBeatmapURLPattern = regexp.MustCompile(`/(?:b|beatmaps)/(\d+)`)
+	// A link to a whole beatmapset, which does not say which difficulty.
+	poolSetURLPattern = regexp.MustCompile(`/(?:s|beatmapsets)/\d+`)
+)
+
+// parsePoolBeatmapID reads a beatmap ID, or a link to a difficulty.
+func parsePoolBeatmapID(field string) (int64, bool) {
+	field = strings.TrimSpace(field)
+	if m := poolModeURLPattern.FindStringSubmatch(field); m != nil {
+		field = m[1]
+	} else if m := poolBeatmapURLPattern.FindStringSubmatch(field); m != nil {
+		field = m[1]
+	}
+	id, err := strconv.ParseInt(field, 10, 64)
+	return id, err == nil && id > 0
+}
+
+// readMappool reads the slot and beatmap ID columns of a mappool CSV. Rows
+// with an empty second column are skipped, as is the first row when it is
+// not a beatmap (a header). Other rows whose second column is not a beatmap
+// ID or difficulty link are returned as invalid.
+func readMappool(r io.Reader) (slots []poolSlot, invalid []invalidPoolRow, err error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	for first := true; ; first = false {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(record) < 2 || strings.TrimSpace(record[1]) == "" {
+			continue
+		}
+		slot := strings.ToUpper(strings.TrimSpace(record[0]))
+		id, ok := parsePoolBeatmapID(record[1])
+		if ok {
+			slots = append(slots, poolSlot{Slot: slot, BeatmapID: id})
+			continue
+		}
+		if first {
+			continue
+		}
+
+		line, _ := cr.FieldPos(1)
+		row := invalidPoolRow{Line: line, Slot: slot, Cell: strings.TrimSpace(record[1]), Reason: i18n.T("not a beatmap ID or difficulty link")}
+		if poolSetURLPattern.MatchString(row.Cell) {
+			row.Reason = i18n.T("links to a beatmapset, not a difficulty")
+		}
+		invalid = append(invalid, row)
+	}
+	return slots, invalid, nil
+}
+
+func runImportMappool(args []string) error {
+	var g globals
+	fs := newEditFlagSet("import mappool", "<pool.csv>", &g)
+	addDownloadFlags(fs)
+	name := fs.String("name", "", i18n.T("Name of the collection, or the prefix of the per-bracket collections"))
+	perBracket := fs.Bool("per-bracket", false, i18n.T("Create one collection per mod bracket, named like <name> NM"))
+	replace := fs.Bool("replace", false, i18n.T("Overwrite the collections if they exist"))
+	noDownload := fs.Bool("no-download", false, i18n.T("Do not download missing beatmapsets"))
+	dryRun := fs.Bool("dry-run", false, i18n.T("Only show the resolved slots"))
+	skipInvalid := fs.Bool("skip-invalid", false, i18n.T("Skip rows whose beatmap cannot be read instead of failing"))
+	if err := parseEditArgs(&g, fs, args, 1, 1); err != nil {
+		return err
+	}
+	if *name == "" {
+		fs.Usage()
+		return i18n.Errorf(errUsage, "--name is required")
+	}
+
+	e, err := g.load()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return i18n.Errorf(nil, "failed to read %s: %v", fs.Arg(0), err)
+	}
+	slots, invalid, err := readMappool(f)
+	f.Close()
+	if err != nil {
+		return i18n.Errorf(nil, "failed to read %s: %v", fs.Arg(0), err)
+	}
+	for _, row := range invalid {
+		e.infof("Row %s (line %d): %q %s\n", row.Slot, row.Line, row.Cell, row.Reason)
+	}
+	if len(invalid) > 0 && !*skipInvalid {
+		return i18n.Errorf(nil, "%d rows could not be read; fix them or pass --skip-invalid", len(invalid))
+	}
+	if len(slots) == 0 {
+		return i18n.Errorf(nil, "no beatmaps found in %s", fs.Arg(0))
+	}
+
+	if err := e.requireOsu(); err != nil {
+		return err
+	}
+	if err := e.resolveSlots(slots); err != nil {
+		return err
+	}
+
+	// Collections in order of the first slot of each bracket, their
+	// beatmaps in slot order.
+	var names []string
+	hashes := make(map[string][]string)
+	for _, s := range slots {
+		target := *name
+		if *perBracket {
+			target = strings.TrimSpace(*name + " " + s.bracket())
+		}
+		if _, ok := hashes[target]; !ok {
+			names = append(names, target)
+		}
+		hashes[target] = append(hashes[target], s.Hash)
+	}
+
+	if *dryRun {
+		if e.format == "json" {
+			return e.printJSON(slots)
+		}
+		for _, s := range slots {
+			fmt.Fprintf(e.out, "%-6s %-10d %s  %d\n", s.Slot, s.BeatmapID, s.Hash, s.SetID)
+		}
+		return nil
+	}
+
+	err = editCollections(&g, func(e *env, collections *db.CollectionDB) error {
+		for _, target := range names {
+			if err := storeCollection(collections, target, hashes[target], *replace); err != nil {
+				return err
+			}
+			e.infof("Wrote %d beatmaps to collection %q\n", len(hashes[target]), target)
+		}
+		return nil
+	})
+	if err != nil || *noDownload {
+		return err
+	}
+
+	setIDs := make(map[string]int64, len(slots))
+	for _, s := range slots {
+		setIDs[s.Hash] = s.SetID
+	}
+	return e.downloadMissingSets(setIDs)
+}
+
+// resolveSlots fills in the hash and set of every slot, from osu!.db when the
+// beatmap is installed and through the osu! API otherwise. It fails if any
+// slot cannot be resolved, as a pool with gaps would be misleading.
+func (e *env) resolveSlots(slots []poolSlot) error {
+	beatmaps, err := db.ReadStable(e.osuDBPath(), db.LoadOsuDBForHash)
+	if err != nil {
+		return i18n.Errorf(nil, "failed to read osu!.db: %v", err)
+	}
+	byID := make(map[int64]db.Difficulty2, len(beatmaps))
+	for _, b := range beatmaps {
+		if b.BeatmapID > 0 {
+			byID[int64(b.BeatmapID)] = b
+		}
+	}
+
+	var dl *downloader.Downloader
+	failed := 0
+	for i := range slots {
+		s := &slots[i]
+		if b, ok := byID[s.BeatmapID]; ok {
+			s.Hash, s.SetID = b.Hash, int64(b.BeatmapsetID)
+			continue
+		}
+
+		if dl == nil {
+			if dl, err = e.newDownloader(downloader.TypeFull); err != nil {
+				return err
+			}
+		}
+		ref, err := dl.LookupBeatmap(s.BeatmapID)
+		if err != nil {
+			e.infof("Could not resolve %s (beatmap %d): %v\n", s.Slot, s.BeatmapID, err)
+			failed++
+			continue
+		}
+		s.Hash, s.SetID = ref.Hash, ref.SetID
+	}
+	if failed > 0 {
+		return i18n.Errorf(nil, "%d of %d slots could not be resolved", failed, len(slots))
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"OsuCollectionTab/db"
+	"OsuCollectionTab/i18n"
+	"OsuCollectionTab/utils"
+)
+
+func runCollectionsMerge(args []string) error {
+	var g globals
+	fs := newEditFlagSet("collections merge", "<collection.db>...", &g)
+	strategyName := fs.String("strategy", string(db.MergeUnion), i18n.T("Same-named collections: union, keep-ours, keep-theirs or rename-with-suffix"))
+	basePath := fs.String("base", "", i18n.T("Merge into this collection.db instead of the installation's"))
+	outPath := fs.String("o", "", i18n.T("Write the result to this file instead of the installation's collection.db"))
+	dryRun := fs.Bool("dry-run", false, i18n.T("Only show the changes"))
+	yes := fs.Bool("yes", false, i18n.T("Write without asking for confirmation"))
+	if err := parseEditArgs(&g, fs, args, 1, -1); err != nil {
+		return err
+	}
+	strategy, err := db.ParseMergeStrategy(*strategyName)
+	if err != nil {
+		return i18n.Errorf(errUsage, "%v", err)
+	}
+
+	e, err := g.load()
+	if err != nil {
+		return err
+	}
+
+	// Only the installation needs the lock; a base file merged into a
+	// separate output leaves it untouched.
+	target := *outPath
+	inPlace := target == ""
+	if inPlace || *basePath == "" {
+		if err := e.requireOsu(); err != nil {
+			return err
+		}
+	}
+	if inPlace {
+		target = e.collectionDBPath()
+		l, err := e.lockOsu()
+		if err != nil {
+			return err
+		}
+		defer l.Release()
+	}
+
+	var ours *db.CollectionDB
+	if *basePath != "" {
+		ours, err = readCollectionFile(*basePath)
+	} else {
+		ours, err = e.loadCollections()
+	}
+	if err != nil {
+		return err
+	}
+
+	theirs := make([]*db.CollectionDB, 0, fs.NArg())
+	for _, path := range fs.Args() {
+		other, err := readCollectionFile(path)
+		if err != nil {
+			return err
+		}
+		theirs = append(theirs, other)
+	}
+
+	merged := db.Merge(ours, theirs, strategy)
+	diff := db.DiffCollections(ours, merged)
+	if err := e.printCollectionDiff(diff); err != nil {
+		return err
+	}
+
+	if *dryRun || inPlace && diff.Empty() {
+		return nil
+	}
+	if ok, err := e.confirmWrite(*yes, i18n.T("Write the merged collections to %s?", target)); !ok {
+		return err
+	}
+
+	backup, err := db.SaveCollections(target, merged, e.cfg.BackupRetention)
+	if err != nil {
+		return err
+	}
+	if backup != "" {
+		e.infof("Backup written to %s\n", backup)
+	}
+	e.infof("Merged collections written to %s\n", target)
+	return nil
+}
+
+// readCollectionFile reads a collection.db given on the command line, or a
+// Collection Manager .osdb file when the name ends in .osdb.
+func readCollectionFile(path string) (*db.CollectionDB, error) {
+	if isOsdbFile(path) {
+		osdb, err := readOsdbFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return osdb.CollectionDB(), nil
+	}
+
+	collections, err := db.ReadStable(path, db.ReadCollections)
+	if err != nil {
+		return nil, i18n.Errorf(nil, "failed to read %s: %v", path, err)
+	}
+	return collections, nil
+}
+
+// isOsdbFile reports whether path names a .osdb file.
+func isOsdbFile(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".osdb")
+}
+
+func readOsdbFile(path string) (*db.Osdb, error) {
+	osdb, err := db.ReadStable(path, db.ReadOsdb)
+	if err != nil {
+		return nil, i18n.Errorf(nil, "failed to read %s: %v", path, err)
+	}
+	return osdb, nil
+}
+
+// confirmWrite asks question on the terminal unless yes is set. Without a
+// terminal it refuses, so that scripts have to pass --yes.
+func (e *env) confirmWrite(yes bool, question string) (bool, error) {
+	if yes {
+		return true, nil
+	}
+	if !utils.IsTerminal(os.Stdin) {
+		return false, i18n.Errorf(errUsage, "refusing to write without confirmation: pass --yes")
+	}
+	ok, err := utils.Confirm(question)
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		e.infof("Nothing was written.\n")
+	}
+	return ok, nil
+}
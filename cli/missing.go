@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+
+	"OsuCollectionTab/i18n"
+)
+
+// missingEntry is a collection beatmap that is not installed.
+type missingEntry struct {
+	Hash        string   `json:"hash"`
+	Collections []string `json:"collections"`
+}
+
+func runMissing(args []string) error {
+	var g globals
+	fs := newFlagSet("missing", &g)
+	fromProfile := fs.String("from-profile", "", i18n.T("Sync the collections of this profile into the current one"))
+	fromFile := fs.String("from", "", i18n.T("List the missing beatmaps of this collection.db or .osdb file"))
+	if err := g.parse(fs, args); err != nil {
+		return err
+	}
+	if *fromFile != "" && *fromProfile != "" {
+		return i18n.Errorf(errUsage, "--from and --from-profile cannot be used together")
+	}
+	if *fromFile != "" && !isCollectionFile(*fromFile) {
+		return i18n.Errorf(errUsage, "--from needs a collection.db or .osdb file")
+	}
+
+	e, err := g.load()
+	if err != nil {
+		return err
+	}
+	if err := e.requireOsu(); err != nil {
+		return err
+	}
+
+	osuHashes, err := e.loadOsuHashes()
+	if err != nil {
+		return err
+	}
+	collections, _, err := e.loadSourceCollections(*fromProfile, *fromFile)
+	if err != nil {
+		return err
+	}
+
+	names := collectionsByHash(collections)
+	missing := missingHashes(osuHashes, collections)
+	entries := make([]missingEntry, 0, len(missing))
+	for hash := range missing {
+		entries = append(entries, missingEntry{Hash: hash, Collections: names[hash]})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Hash < entries[j].Hash })
+
+	if e.format == "json" {
+		return e.printJSON(entries)
+	}
+
+	for i, c := range collections.Collections {
+		count := 0
+		for _, hash := range c.Hashes {
+			if _, ok := missing[hash]; !ok {
+				continue
+			}
+			if count == 0 {
+				if i > 0 {
+					fmt.Fprintln(e.out)
+				}
+				fmt.Fprintf(e.out, "%s\n", c.Name)
+			}
+			fmt.Fprintf(e.out, "  %s\n", hash)
+			count++
+		}
+	}
+	e.infof("\n%d missing beatmaps.\n", len(entries))
+	return nil
+}
@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"OsuCollectionTab/db"
+	"OsuCollectionTab/downloader"
+	"OsuCollectionTab/i18n"
+	"OsuCollectionTab/secret"
+)
+
+func (e *env) reportUnresolved(dl *downloader.Downloader, collections *db.CollectionDB, reportPath string) {
+	unresolved := dl.Unresolved()
+	if len(unresolved) == 0 {
+		return
+	}
+
+	printUnresolvedSummary(e.info(), collections, unresolved)
+	if err := writeUnresolvedReport(reportPath, collections, unresolved); err != nil {
+		e.infof("Failed to write unresolved report: %v\n", err)
+	} else {
+		e.infof("Unresolved beatmaps were written to %s\n", reportPath)
+	}
+}
+
+// unresolvedByCollection groups unresolved hashes by the collections that contain them.
+// The result is indexed like collections.Collections.
+func unresolvedByCollection(collections *db.CollectionDB, unresolved []downloader.Unresolved) [][]downloader.Unresolved {
+	byHash := make(map[string]downloader.Unresolved, len(unresolved))
+	for _, u := range unresolved {
+		byHash[u.Hash] = u
+	}
+
+	grouped := make([][]downloader.Unresolved, len(collections.Collections))
+	for i, c := range collections.Collections {
+		for _, hash := range c.Hashes {
+			if u, ok := byHash[hash]; ok {
+				grouped[i] = append(grouped[i], u)
+			}
+		}
+	}
+	return grouped
+}
+
+func printUnresolvedSummary(w io.Writer, collections *db.CollectionDB, unresolved []downloader.Unresolved) {
+	fmt.Fprint(w, i18n.T("\n%d beatmaps could not be resolved to a beatmapset:\n", len(unresolved)))
+
+	grouped := unresolvedByCollection(collections, unresolved)
+	for i, c := range collections.Collections {
+		entries := grouped[i]
+		if len(entries) == 0 {
+			continue
+		}
+
+		reasons := make(map[downloader.UnresolvedReason]int)
+		for _, u := range entries {
+			reasons[u.Reason]++
+		}
+
+		parts := make([]string, 0, len(reasons))
+		for _, reason := range []downloader.UnresolvedReason{
+			downloader.ReasonNotFound, downloader.ReasonHTTP, downloader.ReasonDecode, downloader.ReasonNoToken,
+		} {
+			if n := reasons[reason]; n > 0 {
+				parts = append(parts, fmt.Sprintf("%d %s", n, i18n.T(string(reason))))
+			}
+		}
+		fmt.Fprintf(w, "  %s: %d (%s)\n", c.Name, len(entries), strings.Join(parts, ", "))
+	}
+}
+
+// writeUnresolvedReport writes one tab-separated line per collection and hash.
+func writeUnresolvedReport(path string, collections *db.CollectionDB, unresolved []downloader.Unresolved) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	fmt.Fprintln(w, "# collection\thash\treason\tdetail")
+
+	grouped := unresolvedByCollection(collections, unresolved)
+	for i, c := range collections.Collections {
+		for _, u := range grouped[i] {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.Name, u.Hash, u.Reason, secret.Redact(fmt.Sprint(u.Err)))
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"OsuCollectionTab/db"
+	"OsuCollectionTab/downloader"
+)
+
+const (
+	reportToken = "r3port-t0ken+/="
+	reportHash  = "0123456789abcdef0123456789abcdef"
+)
+
+func assertNoToken(t *testing.T, what, s string) {
+	t.Helper()
+	for _, leak := range []string{reportToken, url.QueryEscape(reportToken)} {
+		if strings.Contains(s, leak) {
+			t.Errorf("%s contains the token: %q", what, s)
+		}
+	}
+}
+
+// unresolvedFromClosedConnection looks up reportHash against a server that
+// drops the connection, whose error quotes the request URL with the token.
+func unresolvedFromClosedConnection(t *testing.T) []downloader.Unresolved {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if conn, _, err := w.(http.Hijacker).Hijack(); err == nil {
+			conn.Close()
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	dl, err := downloader.NewDownloader(t.TempDir(), "", 1, 0, reportToken, downloader.TypeFull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dl.SetAPIURL(srv.URL + "/api")
+	dl.ResolveAll(map[string]struct{}{reportHash: {}})
+
+	unresolved := dl.Unresolved()
+	if len(unresolved) != 1 {
+		t.Fatalf("got %d unresolved hashes, want 1", len(unresolved))
+	}
+	return unresolved
+}
+
+func TestUnresolvedReportIsRedacted(t *testing.T) {
+	unresolved := unresolvedFromClosedConnection(t)
+	collections := &db.CollectionDB{Collections: []db.Collection{{Name: "Pool", Hashes: []string{reportHash}}}}
+
+	path := filepath.Join(t.TempDir(), "unresolved.txt")
+	if err := writeUnresolvedReport(path, collections, unresolved); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), reportHash) {
+		t.Errorf("report does not list the hash:\n%s", data)
+	}
+	assertNoToken(t, "report", string(data))
+
+	var summary bytes.Buffer
+	printUnresolvedSummary(&summary, collections, unresolved)
+	assertNoToken(t, "summary", summary.String())
+}
+
+func TestLogsAreRedacted(t *testing.T) {
+	unresolved := unresolvedFromClosedConnection(t)
+
+	prev := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(prev) })
+
+	for _, format := range []string{"text", "json"} {
+		t.Run(format, func(t *testing.T) {
+			l := logFlags{level: "debug", format: format, file: filepath.Join(t.TempDir(), "log")}
+			if err := l.setup(); err != nil {
+				t.Fatal(err)
+			}
+			slog.Error("Lookup failed", "err", unresolved[0].Err)
+			slog.Debug("Requesting", "url", "https://osu.ppy.sh/api/get_beatmaps?k="+reportToken)
+
+			data, err := os.ReadFile(l.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(data), "Lookup failed") {
+				t.Fatalf("nothing was logged:\n%s", data)
+			}
+			assertNoToken(t, "log", string(data))
+		})
+	}
+}
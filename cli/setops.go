@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"fmt"
+
+	"OsuCollectionTab/db"
+	"OsuCollectionTab/i18n"
+)
+
+func runCollectionsUnion(args []string) error {
+	return runSetOperation("union", args, func(lists [][]string) []string {
+		return db.UnionHashes(lists...)
+	})
+}
+
+func runCollectionsIntersect(args []string) error {
+	return runSetOperation("intersect", args, func(lists [][]string) []string {
+		return db.IntersectHashes(lists...)
+	})
+}
+
+func runCollectionsSubtract(args []string) error {
+	return runSetOperation("subtract", args, func(lists [][]string) []string {
+		return db.SubtractHashes(lists[0], lists[1:]...)
+	})
+}
+
+// runSetOperation combines the hash lists of the named collections with op
+// and either stores the result as a collection or prints its hashes.
+func runSetOperation(name string, args []string, op func(lists [][]string) []string) error {
+	var g globals
+	fs := newEditFlagSet("collections "+name, "<collection> <collection>...", &g)
+	into := fs.String("into", "", i18n.T("Name of the collection to write the result to"))
+	replace := fs.Bool("replace", false, i18n.T("Overwrite the --into collection if it exists"))
+	hashesOnly := fs.Bool("hashes-only", false, i18n.T("Print the resulting hashes instead of writing a collection"))
+	if err := parseEditArgs(&g, fs, args, 2, -1); err != nil {
+		return err
+	}
+	if (*into == "") == !*hashesOnly {
+		fs.Usage()
+		return i18n.Errorf(errUsage, "pass either --into <name> or --hashes-only")
+	}
+
+	combine := func(collections *db.CollectionDB) ([]string, error) {
+		lists := make([][]string, 0, fs.NArg())
+		for _, source := range fs.Args() {
+			hashes, err := collections.Hashes(source)
+			if err != nil {
+				return nil, err
+			}
+			lists = append(lists, hashes)
+		}
+		return op(lists), nil
+	}
+
+	if *hashesOnly {
+		e, err := g.load()
+		if err != nil {
+			return err
+		}
+		if err := e.requireOsu(); err != nil {
+			return err
+		}
+		collections, err := e.loadCollections()
+		if err != nil {
+			return err
+		}
+		hashes, err := combine(collections)
+		if err != nil {
+			return err
+		}
+		if e.format == "json" {
+			return e.printJSON(hashes)
+		}
+		for _, hash := range hashes {
+			fmt.Fprintln(e.out, hash)
+		}
+		return nil
+	}
+
+	return editCollections(&g, func(e *env, collections *db.CollectionDB) error {
+		hashes, err := combine(collections)
+		if err != nil {
+			return err
+		}
+
+		if err := storeCollection(collections, *into, hashes, *replace); err != nil {
+			return err
+		}
+		e.infof("Wrote %d beatmaps to collection %q\n", len(hashes), *into)
+		return nil
+	})
+}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"OsuCollectionTab/db"
+	"OsuCollectionTab/i18n"
+)
+
+// sharedCollection is the JSON output of `collections share`.
+type sharedCollection struct {
+	Name     string `json:"name"`
+	Beatmaps int    `json:"beatmaps"`
+	Code     string `json:"code"`
+}
+
+func runCollectionsShare(args []string) error {
+	var g globals
+	fs := newEditFlagSet("collections share", "<name>", &g)
+	if err := parseEditArgs(&g, fs, args, 1, 1); err != nil {
+		return err
+	}
+	name := fs.Arg(0)
+
+	e, err := g.load()
+	if err != nil {
+		return err
+	}
+	if err := e.requireOsu(); err != nil {
+		return err
+	}
+	collections, err := e.loadCollections()
+	if err != nil {
+		return err
+	}
+	hashes, err := collections.Hashes(name)
+	if err != nil {
+		return err
+	}
+	code, err := db.EncodeShareCode(db.Collection{Name: name, Hashes: hashes})
+	if err != nil {
+		return err
+	}
+
+	if e.format == "json" {
+		return e.printJSON(sharedCollection{Name: name, Beatmaps: len(hashes), Code: code})
+	}
+	// Only the code goes to stdout, so that it can be piped or captured.
+	fmt.Fprint(os.Stderr, i18n.T("Share code for %q (%d beatmaps):\n", name, len(hashes)))
+	fmt.Fprintln(e.out, code)
+	return nil
+}
+
+func runCollectionsImportCode(args []string) error {
+	var g globals
+	fs := newEditFlagSet("collections import-code", "<code>", &g)
+	name := fs.String("name", "", i18n.T("Save under this name instead of the shared one"))
+	replace := fs.Bool("replace", false, i18n.T("Overwrite the collection if it exists"))
+	if err := parseEditArgs(&g, fs, args, 1, -1); err != nil {
+		return err
+	}
+
+	// A code wrapped by a chat client may arrive as several arguments.
+	shared, err := db.DecodeShareCode(strings.Join(fs.Args(), ""))
+	if err != nil {
+		return err
+	}
+	if *name != "" {
+		shared.Name = *name
+	}
+	if shared.Name == "" {
+		return i18n.Errorf(errUsage, "the share code has no collection name, use --name")
+	}
+
+	return editCollections(&g, func(e *env, collections *db.CollectionDB) error {
+		if err := storeCollection(collections, shared.Name, shared.Hashes, *replace); err != nil {
+			return err
+		}
+		e.infof("Imported %d beatmaps into collection %q\n", len(shared.Hashes), shared.Name)
+		return nil
+	})
+}
@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"fmt"
+
+	"OsuCollectionTab/i18n"
+)
+
+// statsOutput is the output of the stats command.
+type statsOutput struct {
+	Beatmaps           int `json:"beatmaps"`
+	Collections        int `json:"collections"`
+	CollectionBeatmaps int `json:"collection_beatmaps"`
+	Missing            int `json:"missing"`
+}
+
+func runStats(args []string) error {
+	var g globals
+	fs := newFlagSet("stats", &g)
+	if err := g.parse(fs, args); err != nil {
+		return err
+	}
+
+	e, collections, osuHashes, err := loadCollectionsAndHashes(&g)
+	if err != nil {
+		return err
+	}
+
+	out := statsOutput{
+		Beatmaps:           len(osuHashes),
+		Collections:        len(collections.Collections),
+		CollectionBeatmaps: len(collections.AllHashes()),
+		Missing:            len(missingHashes(osuHashes, collections)),
+	}
+
+	if e.format == "json" {
+		return e.printJSON(out)
+	}
+	fmt.Fprint(e.out, i18n.T("Beatmaps in osu!.db:          %d\n", out.Beatmaps))
+	fmt.Fprint(e.out, i18n.T("Collections:                  %d\n", out.Collections))
+	fmt.Fprint(e.out, i18n.T("Beatmaps in collections:      %d\n", out.CollectionBeatmaps))
+	fmt.Fprint(e.out, i18n.T("Missing collection beatmaps:  %d\n", out.Missing))
+	return nil
+}
@@ -1,118 +1,372 @@
 package config
 
 import (
-	"fmt"
+	"errors"
+	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"OsuCollectionTab/downloader"
+	"OsuCollectionTab/i18n"
+	"OsuCollectionTab/utils"
 
 	"gopkg.in/yaml.v2"
 )
 
 const (
-	DefaultProxy   = "http://127.0.0.1:7890"
 	DefaultWorkers = 5
 	DefaultDelay   = 1.0
+
+	// DefaultBackupRetention 默认保留的collection.db备份数
+	DefaultBackupRetention = 20
+
+	// EnvPrefix 环境变量前缀，如 OSU_COLLECTION_TAB_WORKERS
+	EnvPrefix = "OSU_COLLECTION_TAB_"
+)
+
+// 错误类型，可以用 errors.Is 判断
+var (
+	ErrNoOsuPath      = errors.New("osu! installation not found")
+	ErrConfigFile     = errors.New("config file error")
+	ErrConfigExists   = errors.New("config file already exists")
+	ErrInvalidValue   = errors.New("invalid config value")
+	ErrUnknownProfile = errors.New("unknown profile")
 )
 
 type Config struct {
-	OsuPath     string `yaml:"osu_path"`
-	Proxy       string `yaml:"proxy"`
-	OsuAPIToken string `yaml:"osu_api_token"`
+	OsuPath      string        `yaml:"osu_path"`
+	SongsDir     string        `yaml:"songs_dir,omitempty"` // 为空时使用osu!配置中的谱面目录
+	Proxy        string        `yaml:"proxy"`               // 为空时使用系统代理环境变量
+	OsuAPIToken  string        `yaml:"osu_api_token"`
+	TokenEnv     string        `yaml:"token_env,omitempty"`  // osu_api_token 为空时从该环境变量读取令牌
+	TokenFile    string        `yaml:"token_file,omitempty"` // 再从该文件读取令牌
+	DownloadType string        `yaml:"download_type"`        // full, novideo 或 mini，为空时交互选择
+	Workers      int           `yaml:"workers"`
+	Delay        float64       `yaml:"delay"` // 两次下载之间的间隔(秒)
+	Mirrors      []string      `yaml:"mirrors"`
+	Timeout      time.Duration `yaml:"timeout"`        // 单次下载超时
+	APITimeout   time.Duration `yaml:"api_timeout"`    // 单次API请求超时
+	Lang         string        `yaml:"lang,omitempty"` // 界面语言 en 或 zh，为空时使用 LANG
+
+	// BackupRetention 保留的collection.db备份数，0表示全部保留
+	BackupRetention int `yaml:"backup_retention"`
+	// OsuCollectorURL osu!collector的地址，测试时可指向本地服务
+	OsuCollectorURL string `yaml:"osucollector_url"`
+
+	// Profiles 命名的osu!安装配置，通过 --profile 选择
+	Profiles       map[string]Profile `yaml:"profiles,omitempty"`
+	DefaultProfile string             `yaml:"default_profile,omitempty"`
+	// Profile 当前生效的配置名，不写入配置文件
+	Profile string `yaml:"-"`
 }
 
+// LoadOptions 描述命令行层的配置来源
+type LoadOptions struct {
+	// ConfigFile 由 --config 指定的配置文件，必须存在
+	ConfigFile string
+	// Values 命令行中显式设置的值，键为配置文件中的键名
+	Values map[string]string
+	// Profile 由 --profile 指定的配置名，为空时依次使用
+	// OSU_COLLECTION_TAB_PROFILE 和 default_profile
+	Profile string
+}
+
+// Load 按优先级合并配置: 命令行 > 环境变量 > 所选profile > --config 文件 > 默认配置文件 > 默认值
+func Load(opts LoadOptions) (*Config, error) {
+	cfg := defaultConfig()
+
+	if path := findConfigFile(); path != "" {
+		if err := cfg.applyFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.ConfigFile != "" {
+		if err := cfg.applyFile(opts.ConfigFile); err != nil {
+			return nil, err
+		}
+	}
+
+	profile := opts.Profile
+	if profile == "" {
+		profile = os.Getenv(EnvPrefix + "PROFILE")
+	}
+	if profile == "" {
+		profile = cfg.DefaultProfile
+	}
+	if profile != "" {
+		if err := cfg.UseProfile(profile); err != nil {
+			return nil, err
+		}
+	}
+
+	envSource := func(key string, err error) error {
+		return i18n.Errorf(nil, "environment variable %s: %v", EnvPrefix+strings.ToUpper(key), err)
+	}
+	if err := cfg.applyValues(envValues(), envSource); err != nil {
+		return nil, err
+	}
+
+	flagSource := func(key string, err error) error {
+		return i18n.Errorf(nil, "command line flag %s: %v", key, err)
+	}
+	if err := cfg.applyValues(opts.Values, flagSource); err != nil {
+		return nil, err
+	}
+
+	if cfg.OsuPath == "" {
+		cfg.OsuPath = findOsuPath()
+	}
+
+	if err := cfg.resolveToken(); err != nil {
+		return nil, err
+	}
+	cfg.registerSecrets()
+
+	return cfg, nil
+}
+
+// LoadConfig 加载配置，找不到osu!安装路径时返回错误
 func LoadConfig() (*Config, error) {
-	if cfg, err := loadFromFile(); err == nil {
-		return cfg, nil
+	cfg, err := Load(LoadOptions{})
+	if err != nil {
+		return nil, err
 	}
-	cfg := defaultConfig()
 	if cfg.OsuPath == "" {
-		return nil, fmt.Errorf("无法找到osu!安装路径,请在配置文件中指定")
+		return nil, i18n.Errorf(ErrNoOsuPath, "cannot find the osu! installation, please set osu_path in the config file")
 	}
 	return cfg, nil
 }
 
-func loadFromFile() (*Config, error) {
+// SearchPaths 返回未指定 --config 时依次查找的配置文件
+func SearchPaths() []string {
 	configPaths := []string{
 		filepath.Join(".config", "config.yaml"),
 		filepath.Join("config.yaml"),
 	}
 
+	if path := UserConfigPath(); path != "" {
+		configPaths = append(configPaths, path)
+	}
+
+	return configPaths
+}
+
+// UserConfigPath 返回用户级配置文件路径，优先使用 XDG_CONFIG_HOME
+func UserConfigPath() string {
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		return filepath.Join(xdg, "osu-collection-tab", "config.yaml")
+	}
 	if homeDir, err := os.UserHomeDir(); err == nil {
-		configPaths = append(configPaths,
-			filepath.Join(homeDir, ".config", "osu-collection-tab", "config.yaml"),
-		)
+		return filepath.Join(homeDir, ".config", "osu-collection-tab", "config.yaml")
 	}
+	return ""
+}
 
-	var lastErr error
-	for _, path := range configPaths {
-		data, err := os.ReadFile(path)
-		if err != nil {
-			lastErr = err
-			continue
+// findConfigFile 返回第一个存在的默认配置文件
+func findConfigFile() string {
+	for _, path := range SearchPaths() {
+		if _, err := os.Stat(path); err == nil {
+			return path
 		}
+	}
+	return ""
+}
 
-		var cfg Config
-		if err := yaml.Unmarshal(data, &cfg); err != nil {
-			lastErr = err
-			continue
-		}
-		if cfg.OsuPath == "" {
-			continue
-		}
+// applyFile 用配置文件中出现的键覆盖当前配置
+func (c *Config) applyFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return i18n.Errorf(ErrConfigFile, "cannot load the config file: %v", err)
+	}
 
-		return &cfg, nil
+	// yaml.Unmarshal 只覆盖文件中出现的字段
+	if err := yaml.UnmarshalStrict(data, c); err != nil {
+		return i18n.Errorf(ErrConfigFile, "failed to parse config file %s: %v", path, err)
 	}
+	return nil
+}
 
-	return nil, fmt.Errorf("无法加载配置文件: %w", lastErr)
+// envValues 收集以 EnvPrefix 开头的环境变量
+func envValues() map[string]string {
+	values := make(map[string]string)
+	for _, key := range Keys() {
+		if v, ok := os.LookupEnv(EnvPrefix + strings.ToUpper(key)); ok {
+			values[key] = v
+		}
+	}
+	return values
 }
 
-func defaultConfig() *Config {
-	return &Config{
-		OsuPath:     findOsuPath(),
-		Proxy:       DefaultProxy,
-		OsuAPIToken: "",
+// Keys 返回所有可配置的键名
+func Keys() []string {
+	return []string{
+		"osu_path", "songs_dir", "proxy", "osu_api_token", "token_env", "token_file", "download_type",
+		"workers", "delay", "mirrors", "timeout", "api_timeout", "lang",
+		"backup_retention", "osucollector_url",
 	}
 }
 
-func findOsuPath() string {
-	if localAppData := os.Getenv("LOCALAPPDATA"); localAppData != "" {
-		path := filepath.Join(localAppData, "osu!")
-		if validateOsuPath(path) {
-			return path
-		}
+// applyValues 按键名设置配置，source 在错误信息中注明值的来源
+func (c *Config) applyValues(values map[string]string, source func(key string, err error) error) error {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
 	}
+	sort.Strings(keys)
 
-	possiblePaths := []string{
-		"C:\\osu!",
-		"D:\\osu!",
-		"D:\\osu",
-		"E:\\osu!",
-		"E:\\osu",
+	var errs []error
+	for _, key := range keys {
+		if err := c.Set(key, values[key]); err != nil {
+			errs = append(errs, source(key, err))
+		}
 	}
+	return errors.Join(errs...)
+}
 
-	for _, path := range possiblePaths {
-		if validateOsuPath(path) {
-			return path
+// Set 按配置文件中的键名设置一个值
+func (c *Config) Set(key, value string) error {
+	var err error
+	switch key {
+	case "osu_path":
+		c.OsuPath = value
+	case "songs_dir":
+		c.SongsDir = value
+	case "proxy":
+		c.Proxy = value
+	case "osu_api_token":
+		c.OsuAPIToken = value
+	case "token_env":
+		c.TokenEnv = value
+	case "token_file":
+		c.TokenFile = value
+	case "download_type":
+		c.DownloadType = value
+	case "workers":
+		c.Workers, err = strconv.Atoi(value)
+	case "delay":
+		c.Delay, err = strconv.ParseFloat(value, 64)
+	case "mirrors":
+		c.Mirrors = nil
+		for _, m := range strings.Split(value, ",") {
+			if m = strings.TrimSpace(m); m != "" {
+				c.Mirrors = append(c.Mirrors, m)
+			}
 		}
+	case "timeout":
+		c.Timeout, err = time.ParseDuration(value)
+	case "api_timeout":
+		c.APITimeout, err = time.ParseDuration(value)
+	case "lang":
+		c.Lang = value
+	case "osucollector_url":
+		c.OsuCollectorURL = value
+	case "backup_retention":
+		c.BackupRetention, err = strconv.Atoi(value)
+	default:
+		return i18n.Errorf(ErrInvalidValue, "unknown config key")
+	}
+	if err != nil {
+		return i18n.Errorf(ErrInvalidValue, "invalid value %q", value)
 	}
+	return nil
+}
 
-	return ""
+func defaultConfig() *Config {
+	return &Config{
+		Workers:         DefaultWorkers,
+		Delay:           DefaultDelay,
+		Mirrors:         []string{downloader.DefaultMirror},
+		Timeout:         downloader.DefaultTimeout,
+		APITimeout:      downloader.DefaultAPITimeout,
+		BackupRetention: DefaultBackupRetention,
+		OsuCollectorURL: downloader.DefaultOsuCollectorURL,
+	}
 }
 
-func validateOsuPath(path string) bool {
-	_, err := os.Stat(filepath.Join(path, "osu!.exe"))
-	return err == nil
+// SongsPath 返回下载目标和扫描目录，优先级: songs_dir >
+// osu!.<用户名>.cfg 中的 BeatmapDirectory > <osu_path>/Songs
+func (c *Config) SongsPath() string {
+	if c.SongsDir != "" {
+		return resolveOsuDir(c.OsuPath, c.SongsDir)
+	}
+	if dir, err := BeatmapDirectory(c.OsuPath); err == nil && dir != "" {
+		return dir
+	}
+	path, _ := utils.FindFold(c.OsuPath, "Songs")
+	return path
 }
 
-// 验证配置有效性
+// Validate 验证配置有效性，一次返回所有问题
 func (c *Config) Validate() error {
+	var errs []error
+
 	if c.OsuPath == "" {
-		return fmt.Errorf("osu_path 不能为空")
+		errs = append(errs, i18n.Errorf(ErrNoOsuPath, "osu_path must not be empty"))
+	} else if !validateOsuPath(c.OsuPath) {
+		errs = append(errs, i18n.Errorf(ErrInvalidValue, "invalid osu! path: %s", c.OsuPath))
+	}
+
+	if c.DownloadType != "" {
+		if _, err := downloader.ParseDownloadType(c.DownloadType); err != nil {
+			errs = append(errs, i18n.Errorf(ErrInvalidValue, "invalid download_type: %q (want full, novideo or mini)", c.DownloadType))
+		}
 	}
 
-	if !validateOsuPath(c.OsuPath) {
-		return fmt.Errorf("无效的osu!路径: %s", c.OsuPath)
+	for name, p := range c.Profiles {
+		if p.DownloadType == "" {
+			continue
+		}
+		if _, err := downloader.ParseDownloadType(p.DownloadType); err != nil {
+			errs = append(errs, i18n.Errorf(ErrInvalidValue, "profile %s: invalid download_type: %q", name, p.DownloadType))
+		}
+	}
+	if c.DefaultProfile != "" {
+		if _, ok := c.Profiles[c.DefaultProfile]; !ok {
+			errs = append(errs, i18n.Errorf(ErrUnknownProfile, "default_profile does not exist: %s", c.DefaultProfile))
+		}
 	}
 
-	return nil
+	if c.Workers < 1 {
+		errs = append(errs, i18n.Errorf(ErrInvalidValue, "workers must be greater than 0: %d", c.Workers))
+	}
+	if c.Delay < 0 {
+		errs = append(errs, i18n.Errorf(ErrInvalidValue, "delay must not be negative: %v", c.Delay))
+	}
+	if c.Timeout <= 0 || c.APITimeout <= 0 {
+		errs = append(errs, i18n.Errorf(ErrInvalidValue, "timeout and api_timeout must be greater than 0"))
+	}
+
+	if c.BackupRetention < 0 {
+		errs = append(errs, i18n.Errorf(ErrInvalidValue, "backup_retention must not be negative: %d", c.BackupRetention))
+	}
+
+	if _, ok := i18n.ParseLang(c.Lang); c.Lang != "" && !ok {
+		errs = append(errs, i18n.Errorf(ErrInvalidValue, "invalid lang: %q (want en or zh)", c.Lang))
+	}
+
+	if c.Proxy != "" {
+		if _, err := downloader.ParseProxy(c.Proxy); err != nil {
+			errs = append(errs, i18n.Errorf(ErrInvalidValue, "invalid proxy URL: %s", c.Proxy))
+		}
+	}
+
+	if u, err := url.Parse(c.OsuCollectorURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs = append(errs, i18n.Errorf(ErrInvalidValue, "invalid osucollector_url: %s", c.OsuCollectorURL))
+	}
+
+	if len(c.Mirrors) == 0 {
+		errs = append(errs, i18n.Errorf(ErrInvalidValue, "mirrors must not be empty"))
+	}
+	for _, m := range c.Mirrors {
+		if u, err := url.Parse(m); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, i18n.Errorf(ErrInvalidValue, "invalid mirror URL: %s", m))
+		}
+	}
+
+	return errors.Join(errs...)
 }
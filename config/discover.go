@@ -0,0 +1,158 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+
+	"OsuCollectionTab/utils"
+)
+
+// discoverEnv 是查找osu!安装路径时依赖的外部环境，
+// 测试时可以指向一棵假的目录树
+type discoverEnv struct {
+	getenv func(string) string
+	home   string
+}
+
+func systemDiscoverEnv() discoverEnv {
+	home, _ := os.UserHomeDir()
+	return discoverEnv{getenv: os.Getenv, home: home}
+}
+
+func findOsuPath() string {
+	return discoverOsuPath(systemDiscoverEnv())
+}
+
+// DiscoverOsuPaths 返回所有检测到的osu!安装路径
+func DiscoverOsuPaths() []string {
+	return discoverOsuPaths(systemDiscoverEnv())
+}
+
+// discoverOsuPath 返回第一个有效的osu!安装路径
+func discoverOsuPath(env discoverEnv) string {
+	if paths := discoverOsuPaths(env); len(paths) > 0 {
+		return paths[0]
+	}
+	return ""
+}
+
+func discoverOsuPaths(env discoverEnv) []string {
+	var found []string
+	seen := make(map[string]bool)
+	for _, path := range candidateOsuPaths(env) {
+		if seen[path] || !validateOsuPath(path) {
+			continue
+		}
+		seen[path] = true
+		found = append(found, path)
+	}
+	return found
+}
+
+// candidateOsuPaths 按优先级列出可能的安装路径: Windows 原生路径，
+// 然后是 Wine 前缀(WINEPREFIX、~/.wine、osu-winello、Lutris)中的路径
+func candidateOsuPaths(env discoverEnv) []string {
+	var paths []string
+
+	if localAppData := env.getenv("LOCALAPPDATA"); localAppData != "" {
+		paths = append(paths, filepath.Join(localAppData, "osu!"))
+	}
+
+	paths = append(paths,
+		"C:\\osu!",
+		"D:\\osu!",
+		"D:\\osu",
+		"E:\\osu!",
+		"E:\\osu",
+	)
+
+	// osu-winello 把游戏装在前缀之外
+	if env.home != "" {
+		paths = append(paths,
+			filepath.Join(env.home, ".local", "share", "osu-wine", "osu!"),
+			filepath.Join(env.home, ".local", "share", "osu-wine", "OSU"),
+			filepath.Join(env.home, "osu!"),
+		)
+	}
+
+	for _, prefix := range winePrefixes(env) {
+		paths = append(paths, osuPathsInPrefix(prefix)...)
+	}
+
+	return paths
+}
+
+// winePrefixes 返回可能安装了osu!的Wine前缀
+func winePrefixes(env discoverEnv) []string {
+	var prefixes []string
+	if prefix := env.getenv("WINEPREFIX"); prefix != "" {
+		prefixes = append(prefixes, prefix)
+	}
+	if env.home == "" {
+		return prefixes
+	}
+
+	prefixes = append(prefixes,
+		filepath.Join(env.home, ".wine"),
+		filepath.Join(env.home, ".local", "share", "wineprefixes", "osu-wineprefix"),
+		filepath.Join(env.home, ".local", "share", "osu-wine", "WINE.win32"),
+		filepath.Join(env.home, "Games", "osu"),
+		filepath.Join(env.home, "Games", "osu!"),
+		filepath.Join(env.home, "Games", "osu-stable"),
+	)
+	return prefixes
+}
+
+// osuPathsInPrefix 列出Wine前缀内的常见安装位置，目录名不区分大小写
+func osuPathsInPrefix(prefix string) []string {
+	driveC, ok := utils.FindFold(prefix, "drive_c")
+	if !ok {
+		return nil
+	}
+
+	var paths []string
+	for _, rel := range [][]string{
+		{"osu!"},
+		{"Program Files", "osu!"},
+		{"Program Files (x86)", "osu!"},
+	} {
+		if path, ok := utils.JoinFold(driveC, rel...); ok {
+			paths = append(paths, path)
+		}
+	}
+
+	usersDir, ok := utils.FindFold(driveC, "users")
+	if !ok {
+		return paths
+	}
+	users, err := os.ReadDir(usersDir)
+	if err != nil {
+		return paths
+	}
+	for _, user := range users {
+		if !user.IsDir() {
+			continue
+		}
+		home := filepath.Join(usersDir, user.Name())
+		for _, rel := range [][]string{
+			{"AppData", "Local", "osu!"},
+			{"Local Settings", "Application Data", "osu!"},
+		} {
+			if path, ok := utils.JoinFold(home, rel...); ok {
+				paths = append(paths, path)
+			}
+		}
+	}
+	return paths
+}
+
+// validateOsuPath 判断目录是否为osu!安装目录: 包含 osu!.exe 或 osu!.db
+// (只复制了数据库的目录也可以使用)，文件名不区分大小写
+func validateOsuPath(path string) bool {
+	for _, name := range []string{"osu!.exe", "osu!.db"} {
+		if _, ok := utils.FindFold(path, name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiscoverOsuPath(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string          // files to create, relative to the temp dir
+		env   map[string]string // values relative to the temp dir
+		want  string            // relative to the temp dir, empty for none
+	}{
+		{
+			name:  "WINEPREFIX",
+			files: []string{"prefix/drive_c/osu!/osu!.exe"},
+			env:   map[string]string{"WINEPREFIX": "prefix"},
+			want:  "prefix/drive_c/osu!",
+		},
+		{
+			name:  "default wine prefix",
+			files: []string{"home/.wine/drive_c/users/me/AppData/Local/osu!/osu!.exe"},
+			want:  "home/.wine/drive_c/users/me/AppData/Local/osu!",
+		},
+		{
+			name:  "case mismatch",
+			files: []string{"home/.wine/drive_c/Program Files/OSU!/OSU!.EXE"},
+			want:  "home/.wine/drive_c/Program Files/OSU!",
+		},
+		{
+			name:  "database only",
+			files: []string{"prefix/drive_c/osu!/osu!.db"},
+			env:   map[string]string{"WINEPREFIX": "prefix"},
+			want:  "prefix/drive_c/osu!",
+		},
+		{
+			name: "WINEPREFIX before default prefix",
+			files: []string{
+				"home/.wine/drive_c/osu!/osu!.exe",
+				"prefix/drive_c/osu!/osu!.exe",
+			},
+			env:  map[string]string{"WINEPREFIX": "prefix"},
+			want: "prefix/drive_c/osu!",
+		},
+		{
+			name:  "no installation",
+			files: []string{"home/.wine/drive_c/osu!/readme.txt"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			for _, file := range tt.files {
+				path := filepath.Join(root, filepath.FromSlash(file))
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, nil, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			env := discoverEnv{
+				getenv: func(key string) string {
+					if v, ok := tt.env[key]; ok {
+						return filepath.Join(root, filepath.FromSlash(v))
+					}
+					return ""
+				},
+				home: filepath.Join(root, "home"),
+			}
+
+			want := ""
+			if tt.want != "" {
+				want = filepath.Join(root, filepath.FromSlash(tt.want))
+			}
+			if got := discoverOsuPath(env); got != want {
+				t.Errorf("discoverOsuPath() = %q, want %q", got, want)
+			}
+		})
+	}
+}
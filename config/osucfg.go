@@ -0,0 +1,140 @@
+package config
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"OsuCollectionTab/i18n"
+)
+
+// ReadOsuCfg 解析osu!的cfg文件，每行为 "Key = Value"，以 # 开头的行为注释
+func ReadOsuCfg(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, i18n.Errorf(ErrConfigFile, "cannot open %s: %v", filepath.Base(path), err)
+	}
+	defer file.Close()
+
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		values[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, i18n.Errorf(ErrConfigFile, "failed to read %s: %v", filepath.Base(path), err)
+	}
+
+	return values, nil
+}
+
+// UserCfgFiles 返回osu!目录下的 osu!.<用户名>.cfg 文件，最近修改的在前
+func UserCfgFiles(osuPath string) []string {
+	entries, err := os.ReadDir(osuPath)
+	if err != nil {
+		return nil
+	}
+
+	type cfgFile struct {
+		path    string
+		modTime int64
+	}
+	var files []cfgFile
+	for _, entry := range entries {
+		name := strings.ToLower(entry.Name())
+		// osu!.cfg 是框架配置，不包含谱面目录
+		if entry.IsDir() || name == "osu!.cfg" || !strings.HasPrefix(name, "osu!.") || !strings.HasSuffix(name, ".cfg") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, cfgFile{filepath.Join(osuPath, entry.Name()), info.ModTime().UnixNano()})
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime > files[j].modTime })
+
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = f.path
+	}
+	return paths
+}
+
+// BeatmapDirectory 返回最近使用的用户cfg中的 BeatmapDirectory，
+// 已解析为本机路径；未配置时返回空字符串
+func BeatmapDirectory(osuPath string) (string, error) {
+	for _, path := range UserCfgFiles(osuPath) {
+		values, err := ReadOsuCfg(path)
+		if err != nil {
+			return "", err
+		}
+		if dir := values["BeatmapDirectory"]; dir != "" {
+			return resolveOsuDir(osuPath, dir), nil
+		}
+	}
+	return "", nil
+}
+
+// resolveOsuDir 把osu!配置中的目录转换为本机路径: 相对路径基于osu!目录，
+// 在Wine下把 "D:\Songs" 这样的盘符路径映射到前缀中的 dosdevices
+func resolveOsuDir(osuPath, dir string) string {
+	if isDrivePath(dir) && runtime.GOOS != "windows" {
+		if path := wineDrivePath(osuPath, dir); path != "" {
+			return path
+		}
+	}
+
+	if runtime.GOOS != "windows" {
+		dir = strings.ReplaceAll(dir, "\\", "/")
+	}
+	if filepath.IsAbs(dir) || isDrivePath(dir) {
+		return filepath.Clean(dir)
+	}
+	return filepath.Join(osuPath, dir)
+}
+
+// isDrivePath 判断是否为 "C:\..." 形式的Windows绝对路径
+func isDrivePath(path string) bool {
+	return len(path) >= 3 && path[1] == ':' && (path[2] == '\\' || path[2] == '/') &&
+		(path[0] >= 'a' && path[0] <= 'z' || path[0] >= 'A' && path[0] <= 'Z')
+}
+
+// wineDrivePath 在osuPath所在的Wine前缀中查找盘符对应的目录
+func wineDrivePath(osuPath, dir string) string {
+	prefix := ""
+	for p := filepath.Clean(osuPath); p != filepath.Dir(p); p = filepath.Dir(p) {
+		if strings.EqualFold(filepath.Base(p), "drive_c") {
+			prefix = filepath.Dir(p)
+			break
+		}
+	}
+	if prefix == "" {
+		return ""
+	}
+
+	drive := strings.ToLower(dir[:1]) + ":"
+	rest := strings.ReplaceAll(dir[3:], "\\", "/")
+
+	root := filepath.Join(prefix, "dosdevices", drive)
+	if _, err := os.Stat(root); err != nil {
+		if drive != "c:" {
+			return ""
+		}
+		root = filepath.Join(prefix, "drive_c")
+	}
+	return filepath.Join(root, filepath.FromSlash(rest))
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"sort"
+	"strings"
+
+	"OsuCollectionTab/i18n"
+)
+
+// Profile 描述一个osu!安装(如比赛客户端和日常客户端)，
+// 非空字段覆盖顶层配置
+type Profile struct {
+	OsuPath      string `yaml:"osu_path,omitempty"`
+	SongsDir     string `yaml:"songs_dir,omitempty"`
+	Proxy        string `yaml:"proxy,omitempty"`
+	OsuAPIToken  string `yaml:"osu_api_token,omitempty"`
+	TokenEnv     string `yaml:"token_env,omitempty"`
+	TokenFile    string `yaml:"token_file,omitempty"`
+	DownloadType string `yaml:"download_type,omitempty"`
+}
+
+// ProfileNames 返回按名称排序的所有profile
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// UseProfile 用指定profile的非空字段覆盖当前配置
+func (c *Config) UseProfile(name string) error {
+	p, ok := c.Profiles[name]
+	if !ok {
+		if len(c.Profiles) == 0 {
+			return i18n.Errorf(ErrUnknownProfile, "unknown profile: %s (the config file defines no profiles)", name)
+		}
+		return i18n.Errorf(ErrUnknownProfile, "unknown profile: %s (available: %s)", name, strings.Join(c.ProfileNames(), ", "))
+	}
+
+	if p.OsuPath != "" {
+		c.OsuPath = p.OsuPath
+		// 换了安装目录后，顶层的 songs_dir 不再适用
+		c.SongsDir = p.SongsDir
+	} else if p.SongsDir != "" {
+		c.SongsDir = p.SongsDir
+	}
+	if p.Proxy != "" {
+		c.Proxy = p.Proxy
+	}
+	if p.OsuAPIToken != "" {
+		c.OsuAPIToken = p.OsuAPIToken
+	} else if p.TokenEnv != "" || p.TokenFile != "" {
+		// profile 自己的令牌来源优先于顶层的令牌
+		c.OsuAPIToken = ""
+		c.TokenEnv = p.TokenEnv
+		c.TokenFile = p.TokenFile
+	}
+	if p.DownloadType != "" {
+		c.DownloadType = p.DownloadType
+	}
+	c.Profile = name
+	return nil
+}
+
+// ForProfile 返回应用了指定profile的配置副本，当前配置不变
+func (c *Config) ForProfile(name string) (*Config, error) {
+	other := *c
+	if err := other.UseProfile(name); err != nil {
+		return nil, err
+	}
+	if err := other.resolveToken(); err != nil {
+		return nil, err
+	}
+	other.registerSecrets()
+	return &other, nil
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"net/url"
+	"os"
+	"strings"
+
+	"OsuCollectionTab/i18n"
+	"OsuCollectionTab/secret"
+)
+
+// resolveToken 在 osu_api_token 为空时依次从 token_env 和 token_file 读取令牌
+func (c *Config) resolveToken() error {
+	if c.OsuAPIToken != "" {
+		return nil
+	}
+
+	if c.TokenEnv != "" {
+		c.OsuAPIToken = strings.TrimSpace(os.Getenv(c.TokenEnv))
+		if c.OsuAPIToken != "" {
+			return nil
+		}
+	}
+
+	if c.TokenFile != "" {
+		data, err := os.ReadFile(expandHome(c.TokenFile))
+		if err != nil {
+			return i18n.Errorf(ErrConfigFile, "failed to read token_file: %v", err)
+		}
+		c.OsuAPIToken = strings.TrimSpace(string(data))
+	}
+	return nil
+}
+
+// registerSecrets 登记配置中的令牌和代理密码，使其不会出现在日志和错误信息中
+func (c *Config) registerSecrets() {
+	secret.Register(c.OsuAPIToken)
+	proxies := []string{c.Proxy}
+	for _, p := range c.Profiles {
+		secret.Register(p.OsuAPIToken)
+		proxies = append(proxies, p.Proxy)
+	}
+
+	for _, proxy := range proxies {
+		if u, err := url.Parse(proxy); err == nil && u.User != nil {
+			if password, ok := u.User.Password(); ok {
+				secret.Register(password)
+			}
+		}
+	}
+}
+
+// expandHome 展开以 ~/ 开头的路径
+func expandHome(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return home + path[1:]
+		}
+	}
+	return path
+}
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"text/template"
+
+	"OsuCollectionTab/i18n"
+)
+
+var configTemplate = template.Must(template.New("config.yaml").Funcs(template.FuncMap{
+	"q": strconv.Quote,
+}).Parse(`# OsuCollectionTab configuration
+# Values here can be overridden by OSU_COLLECTION_TAB_* environment variables
+# and command line flags.
+
+# osu! installation directory (the one containing osu!.db and collection.db)
+osu_path: {{q .OsuPath}}
+
+# Where beatmaps are downloaded to. Leave empty to use BeatmapDirectory from
+# osu!.<username>.cfg, or <osu_path>/Songs.
+# songs_dir: ""
+
+# HTTP proxy for downloads and API requests. Leave empty to use the system
+# proxy environment variables.
+proxy: {{q .Proxy}}
+
+# Legacy osu! API key: osu! website -> Account Settings -> OAuth -> Legacy API
+osu_api_token: {{q .OsuAPIToken}}
+
+# Keep the token out of this file: when osu_api_token is empty it is read from
+# the environment variable token_env, then from the file token_file.
+{{if .TokenEnv}}token_env: {{q .TokenEnv}}{{else}}# token_env: OSU_API_KEY{{end}}
+{{if .TokenFile}}token_file: {{q .TokenFile}}{{else}}# token_file: ~/.osu-token{{end}}
+
+# full, novideo or mini. Leave empty to be asked on every run.
+download_type: {{q .DownloadType}}
+
+# Concurrent downloads and seconds between two downloads
+workers: {{.Workers}}
+delay: {{.Delay}}
+
+# Download mirrors, tried in order. The download type and set ID are appended,
+# or substituted for {type} and {id} when present.
+mirrors:
+{{- range .Mirrors}}
+  - {{q .}}
+{{- end}}
+
+# osu!collector site used by 'import osucollector'
+osucollector_url: {{q .OsuCollectorURL}}
+
+# Timeouts of a single download and a single API request
+timeout: {{.Timeout}}
+api_timeout: {{.APITimeout}}
+
+# Number of collection.db backups kept in collection.db.backups before every
+# write. 0 keeps all of them.
+backup_retention: {{.BackupRetention}}
+
+# Message language: en or zh. Leave empty to follow LANG.
+{{if .Lang}}lang: {{q .Lang}}{{else}}# lang: zh{{end}}
+
+# Named installations, selected with --profile. Non-empty fields override the
+# values above; osu_path, songs_dir, proxy, osu_api_token, token_env,
+# token_file and download_type can be set per profile.
+# default_profile: daily
+# profiles:
+#   daily:
+#     osu_path: "C:\\osu!"
+#   tournament:
+#     osu_path: "D:\\osu! tourney"
+#     token_env: OSU_TOURNEY_API_KEY
+#     download_type: novideo
+`))
+
+// Render 把配置渲染为带注释的 config.yaml 内容
+func (c *Config) Render() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := configTemplate.Execute(&buf, c); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteFile 把配置写入 path，文件已存在且 overwrite 为 false 时返回错误
+func (c *Config) WriteFile(path string, overwrite bool) error {
+	data, err := c.Render()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return i18n.Errorf(ErrConfigFile, "failed to create the config directory: %v", err)
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !overwrite {
+		flags |= os.O_EXCL
+	}
+	// 配置中可能包含API令牌，仅允许当前用户读取
+	f, err := os.OpenFile(path, flags, 0600)
+	if err != nil {
+		if os.IsExist(err) {
+			return i18n.Errorf(ErrConfigExists, "config file already exists: %s", path)
+		}
+		return i18n.Errorf(ErrConfigFile, "failed to write the config file: %v", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return i18n.Errorf(ErrConfigFile, "failed to write the config file: %v", err)
+	}
+	return f.Close()
+}
+
+// Defaults 返回仅包含默认值的配置
+func Defaults() *Config {
+	return defaultConfig()
+}
@@ -0,0 +1,165 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"OsuCollectionTab/i18n"
+)
+
+// ErrBackupNotFound 指定的备份不存在
+var ErrBackupNotFound = errors.New("backup not found")
+
+// backupTimeLayout 备份ID中时间戳的格式，同一秒内的多个备份再加上 -2、-3 等后缀
+const backupTimeLayout = "20060102-150405"
+
+// Backup collection.db的一个备份
+type Backup struct {
+	ID   string    `json:"id"`
+	Path string    `json:"path"`
+	Time time.Time `json:"time"`
+	Size int64     `json:"size"`
+}
+
+// BackupDir 返回path的备份目录 <path>.backups
+func BackupDir(path string) string {
+	return path + ".backups"
+}
+
+// CreateBackup 把path复制到备份目录中以当前时间命名的文件，
+// keep大于0时只保留最新的keep个备份。path不存在时什么也不做，返回空路径
+func CreateBackup(path string, keep int) (string, error) {
+	src, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", i18n.Errorf(ErrOpen, "cannot open %s: %v", filepath.Base(path), err)
+	}
+	defer src.Close()
+
+	dir := BackupDir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", i18n.Errorf(nil, "failed to back up %s: %v", filepath.Base(path), err)
+	}
+
+	stamp := time.Now().Format(backupTimeLayout)
+	id := stamp
+	var dst *os.File
+	for n := 2; ; n++ {
+		dst, err = os.OpenFile(filepath.Join(dir, backupFileName(id)), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if !os.IsExist(err) {
+			break
+		}
+		id = fmt.Sprintf("%s-%d", stamp, n)
+	}
+	if err != nil {
+		return "", i18n.Errorf(nil, "failed to back up %s: %v", filepath.Base(path), err)
+	}
+
+	_, err = io.Copy(dst, src)
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(dst.Name())
+		return "", i18n.Errorf(nil, "failed to back up %s: %v", filepath.Base(path), err)
+	}
+
+	if keep > 0 {
+		pruneBackups(path, keep)
+	}
+	return dst.Name(), nil
+}
+
+// pruneBackups 删除最新的keep个之外的备份，失败时只记录日志
+func pruneBackups(path string, keep int) {
+	backups, err := ListBackups(path)
+	if err != nil || len(backups) <= keep {
+		return
+	}
+	for _, b := range backups[keep:] {
+		if err := os.Remove(b.Path); err != nil {
+			slog.Warn("Failed to remove old backup", "path", b.Path, "err", err)
+		}
+	}
+}
+
+// ListBackups 返回path的所有备份，最新的在前
+func ListBackups(path string) ([]Backup, error) {
+	dir := BackupDir(path)
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return []Backup{}, nil
+	}
+	if err != nil {
+		return nil, i18n.Errorf(ErrOpen, "cannot open %s: %v", dir, err)
+	}
+
+	backups := []Backup{}
+	for _, entry := range entries {
+		id, t, ok := parseBackupName(entry.Name())
+		if !ok || entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		backups = append(backups, Backup{ID: id, Path: filepath.Join(dir, entry.Name()), Time: t, Size: info.Size()})
+	}
+
+	// 同一秒内的备份按后缀排序，-10 要排在 -9 之后
+	sort.Slice(backups, func(i, j int) bool {
+		a, b := backups[i], backups[j]
+		if !a.Time.Equal(b.Time) {
+			return a.Time.After(b.Time)
+		}
+		if len(a.ID) != len(b.ID) {
+			return len(a.ID) > len(b.ID)
+		}
+		return a.ID > b.ID
+	})
+	return backups, nil
+}
+
+// FindBackup 按ID查找path的备份
+func FindBackup(path, id string) (*Backup, error) {
+	backups, err := ListBackups(path)
+	if err != nil {
+		return nil, err
+	}
+	for i := range backups {
+		if backups[i].ID == id {
+			return &backups[i], nil
+		}
+	}
+	return nil, i18n.Errorf(ErrBackupNotFound, "backup %s not found", id)
+}
+
+func backupFileName(id string) string {
+	return "collection-" + id + ".db"
+}
+
+// parseBackupName 从备份文件名中取出ID和备份时间
+func parseBackupName(name string) (string, time.Time, bool) {
+	id, ok := strings.CutPrefix(name, "collection-")
+	if !ok {
+		return "", time.Time{}, false
+	}
+	if id, ok = strings.CutSuffix(id, ".db"); !ok || len(id) < len(backupTimeLayout) {
+		return "", time.Time{}, false
+	}
+	t, err := time.ParseInLocation(backupTimeLayout, id[:len(backupTimeLayout)], time.Local)
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	return id, t, true
+}
@@ -1,20 +1,45 @@
 package db
 
 import (
-	"fmt"
+	"bufio"
 	"encoding/binary"
+	"log/slog"
 	"os"
 	"regexp"
-	"bufio"
+	"strings"
+
+	"OsuCollectionTab/i18n"
 )
 
 var (
 	// MD5正则表达式 - 包级变量避免重复编译
-	md5Regex = regexp.MustCompile(`[a-f0-9]{32}`)
+	md5Regex = regexp.MustCompile(`^[a-f0-9]{32}$`)
 )
 
+// Collection 表示collection.db中的一个收藏夹
+type Collection struct {
+	Name   string   `json:"name"`
+	Hashes []string `json:"hashes"`
+	// Unparsed 不是MD5哈希的条目，原样保留，写回collection.db时不会丢失，位置也不变
+	Unparsed []UnparsedEntry `json:"-"`
+}
+
+// UnparsedEntry 收藏夹中不是MD5哈希的条目
+type UnparsedEntry struct {
+	// Before 条目之前的哈希个数，写回时条目放在 Hashes[Before] 之前
+	Before int
+	Value  string
+}
+
+// CollectionDB 表示整个collection.db文件
+type CollectionDB struct {
+	Version     int32
+	Collections []Collection
+}
+
 // CollectionReader 读取collection.db文件
 type CollectionReader struct {
+	file   *os.File
 	reader *bufio.Reader
 }
 
@@ -22,78 +47,132 @@ type CollectionReader struct {
 func NewCollectionReader(path string) (*CollectionReader, error) {
 	file, err := os.Open(path)
 	if err != nil {
-		return nil, fmt.Errorf("打开collection.db失败: %w", err)
+		return nil, i18n.Errorf(ErrOpen, "cannot open collection.db: %v", err)
 	}
 
 	return &CollectionReader{
+		file:   file,
 		reader: bufio.NewReader(file),
 	}, nil
 }
 
-// ReadAllHashes 读取所有收藏夹中的谱面哈希
-func (cr *CollectionReader) ReadAllHashes() (map[string]bool, error) {
-	hashes := make(map[string]bool)
+// Close 关闭底层文件
+func (cr *CollectionReader) Close() error {
+	return cr.file.Close()
+}
+
+// ReadAll 按顺序读取所有收藏夹
+func (cr *CollectionReader) ReadAll() (*CollectionDB, error) {
+	cdb := &CollectionDB{}
 
 	// 版本号
-	var version int32
-	if err := binary.Read(cr.reader, binary.LittleEndian, &version); err != nil {
-		return nil, fmt.Errorf("读取版本号失败: %w", err)
+	if err := binary.Read(cr.reader, binary.LittleEndian, &cdb.Version); err != nil {
+		return nil, i18n.Errorf(ErrCorrupt, "failed to read the version: %v", err)
 	}
 
 	// 收藏夹数量
 	var collectionCount int32
 	if err := binary.Read(cr.reader, binary.LittleEndian, &collectionCount); err != nil {
-		return nil, fmt.Errorf("读取收藏夹数量失败: %w", err)
+		return nil, i18n.Errorf(ErrCorrupt, "failed to read the collection count: %v", err)
 	}
 
-	if collectionCount == 0 {
-		return hashes, nil
+	if collectionCount < 0 {
+		return nil, i18n.Errorf(ErrCorrupt, "invalid collection count: %d", collectionCount)
 	}
 
+	// 数量来自文件，损坏时可能极大，预分配的容量设上限
+	cdb.Collections = make([]Collection, 0, min(collectionCount, 4096))
 	for i := int32(0); i < collectionCount; i++ {
-		if err := cr.readCollection(hashes); err != nil {
-			return nil, fmt.Errorf("读取第%d个收藏夹失败: %w", i+1, err)
+		collection, err := cr.readCollection()
+		if err != nil {
+			return nil, i18n.Errorf(ErrCorrupt, "failed to read collection %d: %v", i+1, err)
 		}
+		cdb.Collections = append(cdb.Collections, collection)
+	}
+
+	return cdb, nil
+}
+
+// ReadAllHashes 读取所有收藏夹中的谱面哈希
+func (cr *CollectionReader) ReadAllHashes() (map[string]bool, error) {
+	cdb, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
 	}
 
-	return hashes, nil
+	return cdb.AllHashes(), nil
 }
 
 // readCollection 读取单个收藏夹的信息
-func (cr *CollectionReader) readCollection(hashes map[string]bool) error {
+func (cr *CollectionReader) readCollection() (Collection, error) {
+	var collection Collection
+
 	// 读取收藏夹名称
-	if _, err := ParseString(cr.reader, true); err != nil {
-		return fmt.Errorf("读取收藏夹名称失败: %w", err)
+	name, err := ParseString(cr.reader, false)
+	if err != nil {
+		return collection, i18n.Errorf(ErrCorrupt, "failed to read the collection name: %v", err)
 	}
+	collection.Name = name
 
 	// 读取谱面数量
 	var beatmapCount int32
 	if err := binary.Read(cr.reader, binary.LittleEndian, &beatmapCount); err != nil {
-		return fmt.Errorf("读取谱面数量失败: %w", err)
+		return collection, i18n.Errorf(ErrCorrupt, "failed to read the beatmap count: %v", err)
 	}
 
 	// 读取所有谱面哈希
+	if beatmapCount < 0 {
+		return collection, i18n.Errorf(ErrCorrupt, "invalid beatmap count: %d", beatmapCount)
+	}
+	collection.Hashes = make([]string, 0, min(beatmapCount, 4096))
 	for j := int32(0); j < beatmapCount; j++ {
 		hash, err := ParseString(cr.reader, false)
 		if err != nil {
-			return fmt.Errorf("读取第%d个哈希失败: %w", j+1, err)
+			return collection, i18n.Errorf(ErrCorrupt, "failed to read hash %d: %v", j+1, err)
+		}
+
+		// 哈希统一为小写，无法识别的条目原样保留
+		if normalized := strings.ToLower(strings.TrimSpace(hash)); md5Regex.MatchString(normalized) {
+			collection.Hashes = append(collection.Hashes, normalized)
+		} else {
+			collection.Unparsed = append(collection.Unparsed, UnparsedEntry{Before: len(collection.Hashes), Value: hash})
 		}
+	}
+	if len(collection.Unparsed) > 0 {
+		slog.Warn("Kept collection entries that are not beatmap hashes", "collection", collection.Name, "count", len(collection.Unparsed))
+	}
 
-		// 验证并提取MD5哈希
-		if matches := md5Regex.FindString(hash); matches != "" {
-			hashes[matches] = true
+	return collection, nil
+}
+
+// AllHashes 返回所有收藏夹中去重后的谱面哈希
+func (cdb *CollectionDB) AllHashes() map[string]bool {
+	hashes := make(map[string]bool)
+	for _, collection := range cdb.Collections {
+		for _, hash := range collection.Hashes {
+			hashes[hash] = true
 		}
 	}
+	return hashes
+}
+
+// ReadCollections 读取collection.db中的全部收藏夹(便捷函数)
+func ReadCollections(path string) (*CollectionDB, error) {
+	reader, err := NewCollectionReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
 
-	return nil
+	return reader.ReadAll()
 }
 
 // ReadCollectionDB 读取collection.db文件(便捷函数)
 func ReadCollectionDB(path string) (map[string]bool, error) {
-	reader, err := NewCollectionReader(path)
+	cdb, err := ReadCollections(path)
 	if err != nil {
 		return nil, err
 	}
 
-	return reader.ReadAllHashes()
+	return cdb.AllHashes(), nil
 }
@@ -0,0 +1,103 @@
+package db
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// rawCollectionDB encodes one collection holding entries as they are.
+func rawCollectionDB(t *testing.T, name string, entries []string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, int32(CollectionDBVersion))
+	binary.Write(&buf, binary.LittleEndian, int32(1))
+	if err := WriteString(&buf, name); err != nil {
+		t.Fatal(err)
+	}
+	binary.Write(&buf, binary.LittleEndian, int32(len(entries)))
+	for _, entry := range entries {
+		if err := WriteString(&buf, entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.Bytes()
+}
+
+// rawEntries decodes the entries of the only collection in data.
+func rawEntries(t *testing.T, data []byte) []string {
+	t.Helper()
+	r := bufio.NewReader(bytes.NewReader(data))
+	var version, count int32
+	binary.Read(r, binary.LittleEndian, &version)
+	binary.Read(r, binary.LittleEndian, &count)
+	if _, err := ParseString(r, false); err != nil {
+		t.Fatal(err)
+	}
+	binary.Read(r, binary.LittleEndian, &count)
+	entries := make([]string, count)
+	for i := range entries {
+		entry, err := ParseString(r, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries[i] = entry
+	}
+	return entries
+}
+
+func TestCollectionRoundTripKeepsUnparsedEntries(t *testing.T) {
+	const (
+		first  = "00000000000000000000000000abc000"
+		second = "00000000000000000000000000abc001"
+		third  = "00000000000000000000000000abc002"
+	)
+	path := filepath.Join(t.TempDir(), "collection.db")
+	original := []string{first, "not-a-hash", "00000000000000000000000000ABC001", "", third, "trailing"}
+	if err := os.WriteFile(path, rawCollectionDB(t, "Pool", original), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cdb, err := ReadCollections(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := cdb.Collections[0]
+	if want := []string{first, second, third}; !reflect.DeepEqual(c.Hashes, want) {
+		t.Errorf("Hashes = %q, want %q", c.Hashes, want)
+	}
+	wantUnparsed := []UnparsedEntry{{1, "not-a-hash"}, {2, ""}, {3, "trailing"}}
+	if !reflect.DeepEqual(c.Unparsed, wantUnparsed) {
+		t.Errorf("Unparsed = %+v, want %+v", c.Unparsed, wantUnparsed)
+	}
+
+	var out bytes.Buffer
+	if err := WriteCollections(&out, cdb); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{first, "not-a-hash", second, "", third, "trailing"}
+	if got := rawEntries(t, out.Bytes()); !reflect.DeepEqual(got, want) {
+		t.Errorf("written entries = %q, want %q", got, want)
+	}
+}
+
+func TestWriteCollectionsAfterRemovingHashes(t *testing.T) {
+	cdb := &CollectionDB{Collections: []Collection{{
+		Name:     "Pool",
+		Hashes:   []string{"00000000000000000000000000abc000"},
+		Unparsed: []UnparsedEntry{{0, "head"}, {3, "tail"}},
+	}}}
+
+	var out bytes.Buffer
+	if err := WriteCollections(&out, cdb); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"head", "00000000000000000000000000abc000", "tail"}
+	if got := rawEntries(t, out.Bytes()); !reflect.DeepEqual(got, want) {
+		t.Errorf("written entries = %q, want %q", got, want)
+	}
+}
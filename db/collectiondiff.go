@@ -0,0 +1,137 @@
+package db
+
+// CollectionChange 同名收藏夹中增加和移除的哈希
+type CollectionChange struct {
+	Name    string   `json:"name"`
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+}
+
+// CollectionRename 改名的收藏夹及改名前后增加和移除的哈希
+type CollectionRename struct {
+	From    string   `json:"from"`
+	To      string   `json:"to"`
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+}
+
+// CollectionDiff 两个collection.db之间的差异
+type CollectionDiff struct {
+	Added   []Collection       `json:"added"`
+	Removed []Collection       `json:"removed"`
+	Renamed []CollectionRename `json:"renamed"`
+	Changed []CollectionChange `json:"changed"`
+}
+
+// RenameSimilarity 被移除和新增的收藏夹的哈希集合的Jaccard相似度达到该值时视为改名
+const RenameSimilarity = 0.5
+
+// Empty 判断两边是否没有差异
+func (d *CollectionDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Renamed) == 0 && len(d.Changed) == 0
+}
+
+// DiffCollections 按名称比较old和theirs中的收藏夹，同名的多个收藏夹视为一个。
+// 内容相近的一对被移除和新增的收藏夹记为改名
+func DiffCollections(old, theirs *CollectionDB) *CollectionDiff {
+	diff := &CollectionDiff{Added: []Collection{}, Removed: []Collection{}, Renamed: []CollectionRename{}, Changed: []CollectionChange{}}
+	oldByName := hashesByName(old)
+	newByName := hashesByName(theirs)
+
+	for _, name := range collectionNames(old) {
+		if _, ok := newByName[name]; !ok {
+			diff.Removed = append(diff.Removed, Collection{Name: name, Hashes: oldByName[name]})
+		}
+	}
+
+	for _, name := range collectionNames(theirs) {
+		oldHashes, ok := oldByName[name]
+		if !ok {
+			diff.Added = append(diff.Added, Collection{Name: name, Hashes: newByName[name]})
+			continue
+		}
+
+		change := CollectionChange{
+			Name:    name,
+			Added:   SubtractHashes(newByName[name], oldHashes),
+			Removed: SubtractHashes(oldHashes, newByName[name]),
+		}
+		if len(change.Added) > 0 || len(change.Removed) > 0 {
+			diff.Changed = append(diff.Changed, change)
+		}
+	}
+
+	diff.detectRenames()
+	return diff
+}
+
+// detectRenames 为每个被移除的收藏夹找出相似度最高的新增收藏夹，
+// 达到RenameSimilarity时把这一对移到Renamed中
+func (d *CollectionDiff) detectRenames() {
+	matched := make(map[int]bool)
+	var removed []Collection
+	for _, from := range d.Removed {
+		best, bestScore := -1, 0.0
+		for i, to := range d.Added {
+			if matched[i] {
+				continue
+			}
+			if score := similarity(from.Hashes, to.Hashes); score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+		if best < 0 || bestScore < RenameSimilarity {
+			removed = append(removed, from)
+			continue
+		}
+
+		matched[best] = true
+		to := d.Added[best]
+		d.Renamed = append(d.Renamed, CollectionRename{
+			From:    from.Name,
+			To:      to.Name,
+			Added:   SubtractHashes(to.Hashes, from.Hashes),
+			Removed: SubtractHashes(from.Hashes, to.Hashes),
+		})
+	}
+
+	added := make([]Collection, 0, len(d.Added)-len(matched))
+	for i, c := range d.Added {
+		if !matched[i] {
+			added = append(added, c)
+		}
+	}
+	d.Added = added
+	d.Removed = append([]Collection{}, removed...)
+}
+
+// similarity 返回两组哈希的Jaccard相似度，两组都为空时为0
+func similarity(a, b []string) float64 {
+	union := len(UnionHashes(a, b))
+	if union == 0 {
+		return 0
+	}
+	return float64(len(IntersectHashes(a, b))) / float64(union)
+}
+
+// collectionNames 按出现顺序返回去重后的收藏夹名称
+func collectionNames(cdb *CollectionDB) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, c := range cdb.Collections {
+		if !seen[c.Name] {
+			seen[c.Name] = true
+			names = append(names, c.Name)
+		}
+	}
+	return names
+}
+
+// hashesByName 按名称汇总收藏夹中的哈希
+func hashesByName(cdb *CollectionDB) map[string][]string {
+	byName := make(map[string][]string)
+	for _, c := range cdb.Collections {
+		byName[c.Name] = dedupHashes(append(byName[c.Name], c.Hashes...))
+	}
+	return byName
+}
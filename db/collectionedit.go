@@ -0,0 +1,111 @@
+package db
+
+import (
+	"errors"
+
+	"OsuCollectionTab/i18n"
+)
+
+// 收藏夹编辑的错误类型
+var (
+	ErrCollectionNotFound = errors.New("collection not found")
+	ErrCollectionExists   = errors.New("collection already exists")
+)
+
+// Find 返回第一个名为name的收藏夹，找不到时返回nil
+func (cdb *CollectionDB) Find(name string) *Collection {
+	for i := range cdb.Collections {
+		if cdb.Collections[i].Name == name {
+			return &cdb.Collections[i]
+		}
+	}
+	return nil
+}
+
+// Create 新建一个空收藏夹
+func (cdb *CollectionDB) Create(name string) error {
+	if name == "" {
+		return i18n.Errorf(nil, "the collection name must not be empty")
+	}
+	if cdb.Find(name) != nil {
+		return i18n.Errorf(ErrCollectionExists, "collection %q already exists", name)
+	}
+	cdb.Collections = append(cdb.Collections, Collection{Name: name, Hashes: []string{}})
+	return nil
+}
+
+// Rename 重命名收藏夹
+func (cdb *CollectionDB) Rename(oldName, newName string) error {
+	c := cdb.Find(oldName)
+	if c == nil {
+		return i18n.Errorf(ErrCollectionNotFound, "collection %q not found", oldName)
+	}
+	if newName == "" {
+		return i18n.Errorf(nil, "the collection name must not be empty")
+	}
+	if newName != oldName && cdb.Find(newName) != nil {
+		return i18n.Errorf(ErrCollectionExists, "collection %q already exists", newName)
+	}
+	c.Name = newName
+	return nil
+}
+
+// Delete 删除所有名为name的收藏夹
+func (cdb *CollectionDB) Delete(name string) error {
+	kept := cdb.Collections[:0]
+	for _, c := range cdb.Collections {
+		if c.Name != name {
+			kept = append(kept, c)
+		}
+	}
+	if len(kept) == len(cdb.Collections) {
+		return i18n.Errorf(ErrCollectionNotFound, "collection %q not found", name)
+	}
+	cdb.Collections = kept
+	return nil
+}
+
+// Add 把哈希追加到收藏夹末尾，已存在的哈希会被跳过，返回实际添加的数量
+func (cdb *CollectionDB) Add(name string, hashes ...string) (int, error) {
+	c := cdb.Find(name)
+	if c == nil {
+		return 0, i18n.Errorf(ErrCollectionNotFound, "collection %q not found", name)
+	}
+
+	present := make(map[string]bool, len(c.Hashes))
+	for _, hash := range c.Hashes {
+		present[hash] = true
+	}
+	added := 0
+	for _, hash := range hashes {
+		if present[hash] {
+			continue
+		}
+		present[hash] = true
+		c.Hashes = append(c.Hashes, hash)
+		added++
+	}
+	return added, nil
+}
+
+// Remove 从收藏夹中移除哈希，返回实际移除的数量
+func (cdb *CollectionDB) Remove(name string, hashes ...string) (int, error) {
+	c := cdb.Find(name)
+	if c == nil {
+		return 0, i18n.Errorf(ErrCollectionNotFound, "collection %q not found", name)
+	}
+
+	remove := make(map[string]bool, len(hashes))
+	for _, hash := range hashes {
+		remove[hash] = true
+	}
+	kept := c.Hashes[:0]
+	for _, hash := range c.Hashes {
+		if !remove[hash] {
+			kept = append(kept, hash)
+		}
+	}
+	removed := len(c.Hashes) - len(kept)
+	c.Hashes = kept
+	return removed, nil
+}
@@ -0,0 +1,86 @@
+package db
+
+import (
+	"strconv"
+
+	"OsuCollectionTab/i18n"
+)
+
+// MergeStrategy 决定同名收藏夹如何合并
+type MergeStrategy string
+
+const (
+	// MergeUnion 合并两边的谱面
+	MergeUnion MergeStrategy = "union"
+	// MergeKeepOurs 保留已有的收藏夹
+	MergeKeepOurs MergeStrategy = "keep-ours"
+	// MergeKeepTheirs 用合并进来的收藏夹替换已有的
+	MergeKeepTheirs MergeStrategy = "keep-theirs"
+	// MergeRename 两边都保留，合并进来的加上 " (2)" 这样的后缀
+	MergeRename MergeStrategy = "rename-with-suffix"
+)
+
+// MergeStrategies 所有可用的合并策略
+var MergeStrategies = []MergeStrategy{MergeUnion, MergeKeepOurs, MergeKeepTheirs, MergeRename}
+
+// ParseMergeStrategy 解析合并策略名称
+func ParseMergeStrategy(s string) (MergeStrategy, error) {
+	for _, strategy := range MergeStrategies {
+		if MergeStrategy(s) == strategy {
+			return strategy, nil
+		}
+	}
+	return "", i18n.Errorf(nil, "invalid merge strategy %q (want union, keep-ours, keep-theirs or rename-with-suffix)", s)
+}
+
+// Merge 依次把theirs中的收藏夹合并进ours的副本并返回，ours本身不变。
+// 新收藏夹追加在末尾，每个收藏夹中的重复哈希都会被去掉
+func Merge(ours *CollectionDB, theirs []*CollectionDB, strategy MergeStrategy) *CollectionDB {
+	merged := &CollectionDB{Version: ours.Version}
+	for _, c := range ours.Collections {
+		merged.Collections = append(merged.Collections, Collection{Name: c.Name, Hashes: dedupHashes(c.Hashes), Unparsed: c.Unparsed})
+	}
+
+	for _, other := range theirs {
+		for _, c := range other.Collections {
+			existing := merged.Find(c.Name)
+			switch {
+			case existing == nil:
+				merged.Collections = append(merged.Collections, Collection{Name: c.Name, Hashes: dedupHashes(c.Hashes)})
+			case strategy == MergeUnion:
+				existing.Hashes = dedupHashes(append(existing.Hashes, c.Hashes...))
+			case strategy == MergeKeepTheirs:
+				existing.Hashes = dedupHashes(c.Hashes)
+			case strategy == MergeRename:
+				// 内容相同的收藏夹不需要再保留一份
+				if len(SubtractHashes(c.Hashes, existing.Hashes)) > 0 || len(SubtractHashes(existing.Hashes, c.Hashes)) > 0 {
+					merged.Collections = append(merged.Collections, Collection{Name: merged.uniqueName(c.Name), Hashes: dedupHashes(c.Hashes)})
+				}
+			}
+		}
+	}
+	return merged
+}
+
+// uniqueName 返回 "name (2)"、"name (3)" 中第一个未被使用的名称
+func (cdb *CollectionDB) uniqueName(name string) string {
+	for i := 2; ; i++ {
+		candidate := name + " (" + strconv.Itoa(i) + ")"
+		if cdb.Find(candidate) == nil {
+			return candidate
+		}
+	}
+}
+
+// dedupHashes 返回按首次出现顺序去重后的哈希
+func dedupHashes(hashes []string) []string {
+	seen := make(map[string]bool, len(hashes))
+	result := make([]string, 0, len(hashes))
+	for _, hash := range hashes {
+		if !seen[hash] {
+			seen[hash] = true
+			result = append(result, hash)
+		}
+	}
+	return result
+}
@@ -0,0 +1,61 @@
+package db
+
+import "OsuCollectionTab/i18n"
+
+// Hashes 返回名为name的收藏夹中的哈希，同名的多个收藏夹会合并
+func (cdb *CollectionDB) Hashes(name string) ([]string, error) {
+	hashes, ok := hashesByName(cdb)[name]
+	if !ok {
+		return nil, i18n.Errorf(ErrCollectionNotFound, "collection %q not found", name)
+	}
+	return hashes, nil
+}
+
+// UnionHashes 返回出现在任一列表中的哈希，按首次出现的顺序
+func UnionHashes(lists ...[]string) []string {
+	var all []string
+	for _, list := range lists {
+		all = append(all, list...)
+	}
+	return dedupHashes(all)
+}
+
+// IntersectHashes 返回在每个列表中都出现的哈希，保持第一个列表中的顺序
+func IntersectHashes(lists ...[]string) []string {
+	if len(lists) == 0 {
+		return []string{}
+	}
+
+	result := dedupHashes(lists[0])
+	for _, list := range lists[1:] {
+		in := make(map[string]bool, len(list))
+		for _, hash := range list {
+			in[hash] = true
+		}
+		kept := result[:0]
+		for _, hash := range result {
+			if in[hash] {
+				kept = append(kept, hash)
+			}
+		}
+		result = kept
+	}
+	return result
+}
+
+// SubtractHashes 返回第一个列表中不在其他任何列表里的哈希，保持原有顺序
+func SubtractHashes(first []string, others ...[]string) []string {
+	exclude := make(map[string]bool)
+	for _, list := range others {
+		for _, hash := range list {
+			exclude[hash] = true
+		}
+	}
+	result := []string{}
+	for _, hash := range dedupHashes(first) {
+		if !exclude[hash] {
+			result = append(result, hash)
+		}
+	}
+	return result
+}
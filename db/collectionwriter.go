@@ -0,0 +1,88 @@
+package db
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+
+	"OsuCollectionTab/i18n"
+)
+
+// WriteCollections 按collection.db格式写入所有收藏夹
+func WriteCollections(w io.Writer, cdb *CollectionDB) error {
+	bw := bufio.NewWriter(w)
+
+	version := cdb.Version
+	if version == 0 {
+		version = CollectionDBVersion
+	}
+	if err := binary.Write(bw, binary.LittleEndian, version); err != nil {
+		return err
+	}
+	if err := binary.Write(bw, binary.LittleEndian, int32(len(cdb.Collections))); err != nil {
+		return err
+	}
+
+	for _, c := range cdb.Collections {
+		if err := WriteString(bw, c.Name); err != nil {
+			return err
+		}
+		if err := binary.Write(bw, binary.LittleEndian, int32(len(c.Hashes)+len(c.Unparsed))); err != nil {
+			return err
+		}
+		// 读取时无法识别的条目原样写回原来的位置；哈希被删除后超出范围的放在最后
+		unparsed := c.Unparsed
+		for i, hash := range c.Hashes {
+			for len(unparsed) > 0 && unparsed[0].Before <= i {
+				if err := WriteString(bw, unparsed[0].Value); err != nil {
+					return err
+				}
+				unparsed = unparsed[1:]
+			}
+			if err := WriteString(bw, hash); err != nil {
+				return err
+			}
+		}
+		for _, entry := range unparsed {
+			if err := WriteString(bw, entry.Value); err != nil {
+				return err
+			}
+		}
+	}
+
+	return bw.Flush()
+}
+
+// SaveCollections 把收藏夹写回path: 先用CreateBackup备份原文件并只保留最新的keep个备份，
+// 再写入同目录下的临时文件并重命名，写入中途失败不会损坏原文件。
+// 返回备份文件路径，原文件不存在时为空
+func SaveCollections(path string, cdb *CollectionDB, keep int) (string, error) {
+	backup, err := CreateBackup(path, keep)
+	if err != nil {
+		return "", err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".collection-*.db.tmp")
+	if err != nil {
+		return "", i18n.Errorf(nil, "failed to write %s: %v", filepath.Base(path), err)
+	}
+	defer os.Remove(tmp.Name())
+
+	// 保留原文件的权限，CreateTemp 创建的文件只有所有者可读
+	if info, statErr := os.Stat(path); statErr == nil {
+		tmp.Chmod(info.Mode().Perm())
+	}
+	err = WriteCollections(tmp, cdb)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		return "", i18n.Errorf(nil, "failed to write %s: %v", filepath.Base(path), err)
+	}
+	return backup, nil
+}
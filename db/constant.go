@@ -13,6 +13,9 @@ const (
 
 	// VersionWithFloatStarRating 星级评分从Double改为Float的版本
 	VersionWithFloatStarRating = 20250107
+
+	// CollectionDBVersion 新建collection.db时写入的版本号
+	CollectionDBVersion = 20150203
 )
 
 // 数据类型大小常量
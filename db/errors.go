@@ -0,0 +1,11 @@
+package db
+
+import "errors"
+
+// 错误类型，可以用 errors.Is 判断
+var (
+	// ErrOpen 数据库文件无法打开
+	ErrOpen = errors.New("cannot open database")
+	// ErrCorrupt 数据库内容不完整或格式错误
+	ErrCorrupt = errors.New("corrupt database")
+)
@@ -0,0 +1,357 @@
+package db
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"OsuCollectionTab/i18n"
+)
+
+// ErrOsdbVersion .osdb文件的版本不受支持
+var ErrOsdbVersion = errors.New("unsupported .osdb version")
+
+// Collection Manager的.osdb格式常量
+const (
+	// OsdbVersion 写入.osdb时使用的版本
+	OsdbVersion = "o!dm8"
+	// osdbFooter 文件末尾的固定字符串
+	osdbFooter = "By Piotrekol"
+	// osdbEditor 写入文件时记录的编辑者
+	osdbEditor = "OsuCollectionTab"
+)
+
+// osdbVersions .osdb版本字符串对应的版本号，min结尾的为不含谱面信息的精简格式
+var osdbVersions = map[string]int{
+	"o!dm": 1, "o!dm2": 2, "o!dm3": 3, "o!dm4": 4,
+	"o!dm5": 5, "o!dm6": 6, "o!dm7": 7, "o!dm8": 8,
+	"o!dm7min": 7, "o!dm8min": 8,
+}
+
+// OsdbBeatmap .osdb收藏夹中带谱面信息的一个谱面
+type OsdbBeatmap struct {
+	BeatmapID  int32   `json:"beatmap_id"`
+	SetID      int32   `json:"set_id"`
+	Artist     string  `json:"artist,omitempty"`
+	Title      string  `json:"title,omitempty"`
+	Difficulty string  `json:"difficulty,omitempty"`
+	MD5        string  `json:"md5"`
+	Comment    string  `json:"comment,omitempty"`
+	Mode       byte    `json:"mode"`
+	Stars      float64 `json:"stars,omitempty"`
+}
+
+// OsdbCollection .osdb中的一个收藏夹
+type OsdbCollection struct {
+	Name     string        `json:"name"`
+	OnlineID int32         `json:"online_id"`
+	Beatmaps []OsdbBeatmap `json:"beatmaps"`
+	// HashOnly 只有哈希、没有谱面信息的谱面
+	HashOnly []string `json:"hash_only"`
+}
+
+// Osdb 表示整个.osdb文件
+type Osdb struct {
+	Version     string           `json:"version"`
+	Date        time.Time        `json:"date"`
+	Editor      string           `json:"editor"`
+	Collections []OsdbCollection `json:"collections"`
+}
+
+// ReadOsdb 读取.osdb文件(便捷函数)
+func ReadOsdb(path string) (*Osdb, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, i18n.Errorf(ErrOpen, "cannot open %s: %v", path, err)
+	}
+	defer file.Close()
+
+	return ParseOsdb(file)
+}
+
+// ParseOsdb 从r中解析.osdb内容
+func ParseOsdb(r io.Reader) (*Osdb, error) {
+	or := &osdbReader{r: bufio.NewReader(r)}
+	osdb := &Osdb{Version: or.string()}
+	if or.err != nil {
+		return nil, i18n.Errorf(ErrCorrupt, "failed to read the .osdb version: %v", or.err)
+	}
+	version, ok := osdbVersions[osdb.Version]
+	if !ok {
+		return nil, i18n.Errorf(ErrOsdbVersion, "unsupported .osdb version %q", osdb.Version)
+	}
+	minimal := strings.HasSuffix(osdb.Version, "min")
+
+	// 从第7版起，版本字符串之后的内容经过gzip压缩，并重复一次版本字符串
+	if version >= 7 {
+		zr, err := gzip.NewReader(or.r)
+		if err != nil {
+			return nil, i18n.Errorf(ErrCorrupt, "failed to decompress the .osdb file: %v", err)
+		}
+		defer zr.Close()
+		or.r = bufio.NewReader(zr)
+		if again := or.string(); or.err == nil && again != osdb.Version {
+			return nil, i18n.Errorf(ErrCorrupt, "the .osdb version %q does not match %q", again, osdb.Version)
+		}
+	}
+
+	if version >= 2 {
+		osdb.Date = fromOADate(or.float64())
+	}
+	osdb.Editor = or.string()
+
+	count := or.int32()
+	for i := int32(0); i < count && or.err == nil; i++ {
+		c := OsdbCollection{Name: or.string(), OnlineID: -1, Beatmaps: []OsdbBeatmap{}, HashOnly: []string{}}
+		if version >= 7 {
+			c.OnlineID = or.int32()
+		}
+
+		beatmaps := or.int32()
+		for j := int32(0); j < beatmaps && or.err == nil; j++ {
+			b := OsdbBeatmap{BeatmapID: or.int32(), SetID: -1}
+			if version >= 2 {
+				b.SetID = or.int32()
+			}
+			if !minimal {
+				b.Artist, b.Title, b.Difficulty = or.string(), or.string(), or.string()
+			}
+			b.MD5 = or.string()
+			if version >= 4 {
+				b.Comment = or.string()
+			}
+			if version >= 8 || version >= 5 && !minimal {
+				b.Mode = or.byte()
+			}
+			if version >= 8 || version >= 6 && !minimal {
+				b.Stars = or.float64()
+			}
+			c.Beatmaps = append(c.Beatmaps, b)
+		}
+
+		if version >= 3 {
+			hashes := or.int32()
+			for j := int32(0); j < hashes && or.err == nil; j++ {
+				c.HashOnly = append(c.HashOnly, or.string())
+			}
+		}
+		osdb.Collections = append(osdb.Collections, c)
+	}
+	if or.err != nil {
+		return nil, i18n.Errorf(ErrCorrupt, "failed to read the .osdb collections: %v", or.err)
+	}
+
+	if footer := or.string(); or.err != nil || footer != osdbFooter {
+		return nil, i18n.Errorf(ErrCorrupt, "the .osdb file has no valid footer")
+	}
+	return osdb, nil
+}
+
+// WriteOsdb 以完整的o!dm8格式把osdb写入w
+func WriteOsdb(w io.Writer, osdb *Osdb) error {
+	if err := writeDotNetString(w, OsdbVersion); err != nil {
+		return err
+	}
+
+	zw := gzip.NewWriter(w)
+	bw := bufio.NewWriter(zw)
+	ow := &osdbWriter{w: bw}
+
+	editor := osdb.Editor
+	if editor == "" {
+		editor = osdbEditor
+	}
+	date := osdb.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+
+	ow.string(OsdbVersion)
+	ow.value(toOADate(date))
+	ow.string(editor)
+	ow.value(int32(len(osdb.Collections)))
+	for _, c := range osdb.Collections {
+		ow.string(c.Name)
+		ow.value(c.OnlineID)
+		ow.value(int32(len(c.Beatmaps)))
+		for _, b := range c.Beatmaps {
+			ow.value(b.BeatmapID)
+			ow.value(b.SetID)
+			ow.string(b.Artist)
+			ow.string(b.Title)
+			ow.string(b.Difficulty)
+			ow.string(b.MD5)
+			ow.string(b.Comment)
+			ow.value(b.Mode)
+			ow.value(b.Stars)
+		}
+		ow.value(int32(len(c.HashOnly)))
+		for _, hash := range c.HashOnly {
+			ow.string(hash)
+		}
+	}
+	ow.string(osdbFooter)
+
+	if ow.err != nil {
+		return ow.err
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// NewOsdb 用collections创建.osdb内容，info中能找到的谱面带上ID和谱面信息，
+// 其余谱面只记录哈希
+func NewOsdb(collections []Collection, info map[string]Difficulty2) *Osdb {
+	osdb := &Osdb{Version: OsdbVersion, Date: time.Now(), Editor: osdbEditor}
+	for _, c := range collections {
+		oc := OsdbCollection{Name: c.Name, OnlineID: -1, Beatmaps: []OsdbBeatmap{}, HashOnly: []string{}}
+		for _, hash := range c.Hashes {
+			d, ok := info[hash]
+			if !ok {
+				oc.HashOnly = append(oc.HashOnly, hash)
+				continue
+			}
+			oc.Beatmaps = append(oc.Beatmaps, OsdbBeatmap{
+				BeatmapID:  d.BeatmapID,
+				SetID:      d.BeatmapsetID,
+				Artist:     d.Artist,
+				Title:      d.Name,
+				Difficulty: d.Difficulty,
+				MD5:        hash,
+				Mode:       d.Mode,
+			})
+		}
+		osdb.Collections = append(osdb.Collections, oc)
+	}
+	return osdb
+}
+
+// CollectionDB 把.osdb转换为collection.db中的收藏夹，哈希统一为小写
+func (osdb *Osdb) CollectionDB() *CollectionDB {
+	cdb := &CollectionDB{Version: CollectionDBVersion}
+	for _, c := range osdb.Collections {
+		hashes := make([]string, 0, len(c.Beatmaps)+len(c.HashOnly))
+		for _, b := range c.Beatmaps {
+			hashes = append(hashes, strings.ToLower(b.MD5))
+		}
+		for _, hash := range c.HashOnly {
+			hashes = append(hashes, strings.ToLower(hash))
+		}
+		cdb.Collections = append(cdb.Collections, Collection{Name: c.Name, Hashes: dedupHashes(hashes)})
+	}
+	return cdb
+}
+
+// SetIDs 返回.osdb中记录了谱面集ID的哈希及其谱面集ID
+func (osdb *Osdb) SetIDs() map[string]int64 {
+	ids := make(map[string]int64)
+	for _, c := range osdb.Collections {
+		for _, b := range c.Beatmaps {
+			if b.SetID > 0 && b.MD5 != "" {
+				ids[strings.ToLower(b.MD5)] = int64(b.SetID)
+			}
+		}
+	}
+	return ids
+}
+
+// oaDateEpoch OLE自动化日期的零点
+var oaDateEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// fromOADate 把OLE自动化日期(距1899-12-30的天数)转换为时间
+func fromOADate(days float64) time.Time {
+	if math.IsNaN(days) || math.IsInf(days, 0) {
+		return time.Time{}
+	}
+	return oaDateEpoch.Add(time.Duration(days * float64(24*time.Hour)))
+}
+
+// toOADate 把时间转换为OLE自动化日期
+func toOADate(t time.Time) float64 {
+	return float64(t.UTC().Sub(oaDateEpoch)) / float64(24*time.Hour)
+}
+
+// osdbReader 按.NET BinaryReader的格式读取，出错后的读取都返回零值，
+// 错误保存在err中
+type osdbReader struct {
+	r   *bufio.Reader
+	err error
+}
+
+func (or *osdbReader) read(v any) {
+	if or.err == nil {
+		or.err = binary.Read(or.r, binary.LittleEndian, v)
+	}
+}
+
+func (or *osdbReader) int32() int32 {
+	var v int32
+	or.read(&v)
+	return v
+}
+
+func (or *osdbReader) float64() float64 {
+	var v float64
+	or.read(&v)
+	return v
+}
+
+func (or *osdbReader) byte() byte {
+	var v byte
+	or.read(&v)
+	return v
+}
+
+// string 读取以ULEB128长度开头的UTF-8字符串，没有osu!格式的0x0b标志
+func (or *osdbReader) string() string {
+	if or.err != nil {
+		return ""
+	}
+	length, err := ParseULEB128(or.r)
+	if err != nil {
+		or.err = err
+		return ""
+	}
+	if length > MaxStringLength {
+		or.err = i18n.Errorf(ErrCorrupt, "string too long: %d", length)
+		return ""
+	}
+	buf := make([]byte, length)
+	if _, err := io.ReadFull(or.r, buf); err != nil {
+		or.err = err
+		return ""
+	}
+	return string(buf)
+}
+
+// osdbWriter 按.NET BinaryWriter的格式写入，只保留第一个错误
+type osdbWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (ow *osdbWriter) value(v any) {
+	if ow.err == nil {
+		ow.err = binary.Write(ow.w, binary.LittleEndian, v)
+	}
+}
+
+func (ow *osdbWriter) string(s string) {
+	if ow.err == nil {
+		ow.err = writeDotNetString(ow.w, s)
+	}
+}
+
+// writeDotNetString 写入以ULEB128长度开头的字符串
+func writeDotNetString(w io.Writer, s string) error {
+	_, err := w.Write(append(GetULEB128(uint64(len(s))), s...))
+	return err
+}
@@ -3,16 +3,17 @@ package db
 
 import (
 	"encoding/binary"
-	"fmt"
 	"io"
 	"unicode/utf8"
+
+	"OsuCollectionTab/i18n"
 )
 
 // ParseString 从reader读取OSU字符串格式
 func ParseString(reader io.Reader, skip bool) (string, error) {
 	indicator := make([]byte, 1)
 	if _, err := reader.Read(indicator); err != nil {
-		return "", fmt.Errorf("读取字符串标志失败: %w", err)
+		return "", i18n.Errorf(ErrCorrupt, "failed to read the string indicator: %v", err)
 	}
 
 	switch indicator[0] {
@@ -22,15 +23,15 @@ func ParseString(reader io.Reader, skip bool) (string, error) {
 	case StringIndicatorExists:
 		length, err := ParseULEB128(reader)
 		if err != nil {
-			return "", fmt.Errorf("读取字符串长度失败: %w", err)
+			return "", i18n.Errorf(ErrCorrupt, "failed to read the string length: %v", err)
 		}
 		if length > MaxStringLength {
-			return "", fmt.Errorf("字符串长度过长: %d", length)
+			return "", i18n.Errorf(ErrCorrupt, "string too long: %d", length)
 		}
 
 		if skip {
 			if _, err := io.CopyN(io.Discard, reader, int64(length)); err != nil {
-				return "", fmt.Errorf("跳过字符串内容失败: %w", err)
+				return "", i18n.Errorf(ErrCorrupt, "failed to skip the string content: %v", err)
 			}
 			return "", nil
 		}
@@ -39,18 +40,18 @@ func ParseString(reader io.Reader, skip bool) (string, error) {
 		strBytes := make([]byte, length)
 		if _, err := io.ReadFull(reader, strBytes); err != nil {
 			if err == io.EOF || err == io.ErrUnexpectedEOF {
-				return "", fmt.Errorf("字符串内容不完整，期望长度 %d: %w", length, err)
+				return "", i18n.Errorf(ErrCorrupt, "incomplete string content, expected length %d: %v", length, err)
 			}
-			return "", fmt.Errorf("无法读取字符串内容: %w", err)
+			return "", i18n.Errorf(ErrCorrupt, "cannot read the string content: %v", err)
 		}
 
 		if !utf8.Valid(strBytes) {
-			return "", fmt.Errorf("无效的UTF-8编码")
+			return "", i18n.Errorf(ErrCorrupt, "invalid UTF-8 encoding")
 		}
 
 		return string(strBytes), nil
 	default:
-		return "", fmt.Errorf("无效的字符串标志: 0x%02x", indicator[0])
+		return "", i18n.Errorf(ErrCorrupt, "invalid string indicator: 0x%02x", indicator[0])
 	}
 }
 
@@ -62,7 +63,7 @@ func ParseULEB128(reader io.Reader) (uint64, error) {
 	for {
 		byteVal := make([]byte, 1)
 		if _, err := reader.Read(byteVal); err != nil {
-			return 0, fmt.Errorf("读取ULEB128失败: %w", err)
+			return 0, i18n.Errorf(ErrCorrupt, "failed to read ULEB128: %v", err)
 		}
 
 		result |= uint64(byteVal[0]&0x7F) << shift
@@ -75,7 +76,7 @@ func ParseULEB128(reader io.Reader) (uint64, error) {
 
 		// 防止无限循环
 		if shift > 63 {
-			return 0, fmt.Errorf("ULEB128值过大")
+			return 0, i18n.Errorf(ErrCorrupt, "ULEB128 value too large")
 		}
 	}
 
@@ -104,6 +105,19 @@ func GetULEB128(integer uint64) []byte {
 	return result
 }
 
+// WriteString 以OSU字符串格式写入s，空字符串只写入空标志
+func WriteString(writer io.Writer, s string) error {
+	if s == "" {
+		_, err := writer.Write([]byte{StringIndicatorEmpty})
+		return err
+	}
+
+	buf := append([]byte{StringIndicatorExists}, GetULEB128(uint64(len(s)))...)
+	buf = append(buf, s...)
+	_, err := writer.Write(buf)
+	return err
+}
+
 type TimingPoint struct {
 	BPM         float64
 	Offset      float64
@@ -242,6 +256,6 @@ func ReadType(typeName string, reader io.Reader) (interface{}, error) {
 		return val, err
 
 	default:
-		return nil, fmt.Errorf("未知数据类型: %s", typeName)
+		return nil, i18n.Errorf(nil, "unknown data type: %s", typeName)
 	}
 }
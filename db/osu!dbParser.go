@@ -2,10 +2,11 @@
 package db
 
 import (
-	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"os"
+
+	"OsuCollectionTab/i18n"
 )
 
 // Difficulty2 表示一个谱面难度
@@ -24,6 +25,7 @@ type Difficulty2 struct {
 	APIBeatmapID int32
 	BeatmapID    int32
 	BeatmapsetID int32
+	Mode         byte // 0 osu!, 1 taiko, 2 catch, 3 mania
 }
 
 // Song 表示一个歌曲，包含多个难度的谱面
@@ -47,7 +49,7 @@ func ParseBeatmap(reader io.Reader, version int32) (*Difficulty2, error) {
 	if version < 20191106 {
 		_, err := ReadType("Int", reader)
 		if err != nil {
-			return nil, fmt.Errorf("读取版本特定整数失败: %w", err)
+			return nil, i18n.Errorf(ErrCorrupt, "failed to read the version-specific integer: %v", err)
 		}
 	}
 
@@ -64,7 +66,7 @@ func ParseBeatmap(reader io.Reader, version int32) (*Difficulty2, error) {
 	for _, t := range types {
 		val, err := ReadType(t, reader)
 		if err != nil {
-			return nil, fmt.Errorf("读取谱面数据失败: %w", err)
+			return nil, i18n.Errorf(ErrCorrupt, "failed to read beatmap data: %v", err)
 		}
 		data = append(data, val)
 	}
@@ -93,25 +95,22 @@ func ParseBeatmap(reader io.Reader, version int32) (*Difficulty2, error) {
 	// 读取滑条速度
 	sv, err := ReadType("Double", reader)
 	if err != nil {
-		return nil, fmt.Errorf("读取滑条速度失败: %w", err)
+		return nil, i18n.Errorf(ErrCorrupt, "failed to read the slider velocity: %v", err)
 	}
 
-	fmt.Printf("滑条速度: %v\n", sv)
-
 	// 读取各模式的星级评分
 	star_ratings := make([][]IntFloatPair, 4)
 	for i := 0; i < 4; i++ {
 		numPairs, err := ReadType("Int", reader)
-		fmt.Printf("星级评分数量: %v\n", numPairs)
 		if err != nil {
-			return nil, fmt.Errorf("读取星级评分数量失败: %w", err)
+			return nil, i18n.Errorf(ErrCorrupt, "failed to read the star rating count: %v", err)
 		}
 
 		pairs := make([]IntFloatPair, numPairs.(int32))
 		for j := 0; j < int(numPairs.(int32)); j++ {
 			pair, err := ReadType("IntFloatPair", reader)
 			if err != nil {
-				return nil, fmt.Errorf("读取星级评分对失败: %w", err)
+				return nil, i18n.Errorf(ErrCorrupt, "failed to read a star rating pair: %v", err)
 			}
 			pairs[j] = pair.(IntFloatPair)
 		}
@@ -144,7 +143,7 @@ func ParseBeatmap(reader io.Reader, version int32) (*Difficulty2, error) {
 	for i := 0; i < int(num_timingpoints.(int32)); i++ {
 		tp, err := ReadType("Timingpoint", reader)
 		if err != nil {
-			return nil, fmt.Errorf("读取节奏点失败: %w", err)
+			return nil, i18n.Errorf(ErrCorrupt, "failed to read timing points: %v", err)
 		}
 		timingpoints[i] = tp.(TimingPoint)
 	}
@@ -163,7 +162,7 @@ func ParseBeatmap(reader io.Reader, version int32) (*Difficulty2, error) {
 	for i, t := range more_types {
 		more_data[i], err = ReadType(t, reader)
 		if err != nil {
-			return nil, fmt.Errorf("读取额外谱面数据失败: %w", err)
+			return nil, i18n.Errorf(ErrCorrupt, "failed to read extra beatmap data: %v", err)
 		}
 	}
 
@@ -207,9 +206,11 @@ func ParseBeatmap(reader io.Reader, version int32) (*Difficulty2, error) {
 		BeatmapsetID: beatmap_set_id,
 	}
 
-	log.Printf("加载谱面 %d: %s - %s [%s] by %s",
-		beatmap.BeatmapID, beatmap.Artist,
-		beatmap.Name, beatmap.Difficulty, beatmap.Mapper)
+	// 每张谱面一条，仅在 debug 级别输出
+	slog.Debug("Loaded beatmap",
+		"beatmap_id", beatmap.BeatmapID, "artist", beatmap.Artist,
+		"title", beatmap.Name, "difficulty", beatmap.Difficulty,
+		"mapper", beatmap.Mapper, "slider_velocity", sv)
 
 	return beatmap, nil
 }
@@ -218,7 +219,7 @@ func ParseBeatmap(reader io.Reader, version int32) (*Difficulty2, error) {
 func LoadOsuDB(path string) (*Songs, error) {
 	file, err := os.Open(path)
 	if err != nil {
-		return nil, fmt.Errorf("打开文件失败: %w", err)
+		return nil, i18n.Errorf(ErrOpen, "cannot open osu!.db: %v", err)
 	}
 	defer file.Close()
 
@@ -230,9 +231,8 @@ func LoadOsuDB(path string) (*Songs, error) {
 
 	for _, t := range types {
 		val, err := ReadType(t, file)
-		fmt.Printf("%s值: %v\n", t, val)
 		if err != nil {
-			return nil, fmt.Errorf("读取文件头数据失败: %w", err)
+			return nil, i18n.Errorf(ErrCorrupt, "failed to read the file header: %v", err)
 		}
 		header_data = append(header_data, val)
 	}
@@ -240,14 +240,14 @@ func LoadOsuDB(path string) (*Songs, error) {
 	version := header_data[0].(int32)
 	num_maps := header_data[5].(int32)
 
-	log.Printf("osu!DB 版本 %d，包含 %d 张谱面", version, num_maps)
+	slog.Debug("Reading osu!.db", "version", version, "beatmaps", num_maps)
 
 	// 读取所有谱面
 	beatmaps := make([]Difficulty2, 0, num_maps)
 	for i := 0; i < int(num_maps); i++ {
 		beatmap, err := ParseBeatmap(file, version)
 		if err != nil {
-			return nil, fmt.Errorf("解析谱面失败: %w", err)
+			return nil, i18n.Errorf(ErrCorrupt, "failed to parse a beatmap: %v", err)
 		}
 		beatmaps = append(beatmaps, *beatmap)
 	}
@@ -269,50 +269,73 @@ func LoadOsuDB(path string) (*Songs, error) {
 	return songs, nil
 }
 
+// skipBytes 跳过n个用不到的字节，数据不足时返回ErrCorrupt，
+// 以免之后的字段从错误的位置读取
+func skipBytes(reader io.Reader, n int64) error {
+	if _, err := io.CopyN(io.Discard, reader, n); err != nil {
+		return i18n.Errorf(ErrCorrupt, "failed to read beatmap data: %v", err)
+	}
+	return nil
+}
+
 func ParseBeatmapForHash(reader io.Reader, version int32) (*Difficulty2, error) {
-	for i := 0; i < 7; i++ {
-		_, err := ParseString(reader, true)
+	// 依次为艺术家、艺术家(Unicode)、标题、标题(Unicode)、作者、难度名、音频文件
+	meta := make([]string, 7)
+	for i := range meta {
+		// 只保留艺术家、标题和难度名
+		keep := i == 0 || i == 2 || i == 5
+		val, err := ParseString(reader, !keep)
 		if err != nil {
-			return nil, fmt.Errorf("读取谱面数据失败: %w", err)
+			return nil, i18n.Errorf(ErrCorrupt, "failed to read beatmap data: %v", err)
 		}
+		meta[i] = val
 	}
 
 	val, err := ReadType("String", reader)
 	if err != nil {
-		return nil, fmt.Errorf("读取谱面数据失败: %w", err)
+		return nil, i18n.Errorf(ErrCorrupt, "failed to read beatmap data: %v", err)
 	}
 	md5 := val.(string)
 
 	_, err = ParseString(reader, true)
 	if err != nil {
-		return nil, fmt.Errorf("读取谱面数据失败: %w", err)
+		return nil, i18n.Errorf(ErrCorrupt, "failed to read beatmap data: %v", err)
 	}
 
-	_, err = io.CopyN(io.Discard, reader, 15)
+	if err := skipBytes(reader, 15); err != nil {
+		return nil, err
+	}
 
+	// 20140609之前难度参数是byte，之后是float
+	difficultySize := int64(16)
 	if version < 20140609 {
-		_, err = io.CopyN(io.Discard, reader, 4)
-	} else {
-		_, err = io.CopyN(io.Discard, reader, 16)
+		difficultySize = 4
+	}
+	if err := skipBytes(reader, difficultySize); err != nil {
+		return nil, err
 	}
 
-	_, err = io.CopyN(io.Discard, reader, 8)
+	if err := skipBytes(reader, 8); err != nil {
+		return nil, err
+	}
 
 	for i := 0; i < 4; i++ {
 		numPairs, err := ReadType("Int", reader)
 		if err != nil {
-			return nil, fmt.Errorf("读取星级评分数量失败: %w", err)
+			return nil, i18n.Errorf(ErrCorrupt, "failed to read the star rating count: %v", err)
 		}
 
 		for j := 0; j < int(numPairs.(int32)); j++ {
 			_, err := ReadType("IntFloatPair", reader)
 			if err != nil {
-				return nil, fmt.Errorf("读取星级评分对失败: %w", err)
+				return nil, i18n.Errorf(ErrCorrupt, "failed to read a star rating pair: %v", err)
 			}
 		}
 	}
 
-	_, err = io.CopyN(io.Discard, reader, 12)
+	if err := skipBytes(reader, 12); err != nil {
+		return nil, err
+	}
 
 	// 读取节奏点
 	num_timingpoints, err := ReadType("Int", reader)
@@ -323,11 +346,26 @@ func ParseBeatmapForHash(reader io.Reader, version int32) (*Difficulty2, error)
 	for i := 0; i < int(num_timingpoints.(int32)); i++ {
 		_, err = io.CopyN(io.Discard, reader, 17)
 		if err != nil {
-			return nil, fmt.Errorf("读取节奏点失败: %w", err)
+			return nil, i18n.Errorf(ErrCorrupt, "failed to read timing points: %v", err)
 		}
 	}
 
-	_, err = io.CopyN(io.Discard, reader, 23)
+	// 节奏点之后是 beatmap_id 和 beatmapset_id，之后的14字节用不到，最后1字节是游戏模式
+	ids := make([]int32, 2)
+	for i := range ids {
+		val, err := ReadType("Int", reader)
+		if err != nil {
+			return nil, i18n.Errorf(ErrCorrupt, "failed to read beatmap data: %v", err)
+		}
+		ids[i] = val.(int32)
+	}
+	if err := skipBytes(reader, 14); err != nil {
+		return nil, err
+	}
+	mode, err := ReadType("Byte", reader)
+	if err != nil {
+		return nil, i18n.Errorf(ErrCorrupt, "failed to read beatmap data: %v", err)
+	}
 
 	// 读取更多谱面数据
 	more_types := []string{
@@ -339,22 +377,34 @@ func ParseBeatmapForHash(reader io.Reader, version int32) (*Difficulty2, error)
 	for i, t := range more_types {
 		more_data[i], err = ReadType(t, reader)
 		if err != nil {
-			return nil, fmt.Errorf("读取额外谱面数据失败: %w", err)
+			return nil, i18n.Errorf(ErrCorrupt, "failed to read extra beatmap data: %v", err)
 		}
 	}
 
-	_, err = io.CopyN(io.Discard, reader, 13)
+	if err := skipBytes(reader, 13); err != nil {
+		return nil, err
+	}
 
 	// 如果版本小于20140609，需要读取一个额外的short
 	if version < 20140609 {
-		_, err = io.CopyN(io.Discard, reader, 2)
+		if err := skipBytes(reader, 2); err != nil {
+			return nil, err
+		}
 	}
 
-	_, err = io.CopyN(io.Discard, reader, 5)
+	if err := skipBytes(reader, 5); err != nil {
+		return nil, err
+	}
 
-	// 创建并返回难度对象
+	// 创建并返回难度对象，只包含哈希和ID
 	beatmap := &Difficulty2{
-		Hash: md5,
+		Name:         meta[2],
+		Artist:       meta[0],
+		Difficulty:   meta[5],
+		Hash:         md5,
+		BeatmapID:    ids[0],
+		BeatmapsetID: ids[1],
+		Mode:         mode.(byte),
 	}
 
 	return beatmap, nil
@@ -363,13 +413,13 @@ func ParseBeatmapForHash(reader io.Reader, version int32) (*Difficulty2, error)
 func LoadOsuDBForHash(path string) ([]Difficulty2, error) {
 	file, err := os.Open(path)
 	if err != nil {
-		return nil, fmt.Errorf("打开文件失败: %w", err)
+		return nil, i18n.Errorf(ErrOpen, "cannot open osu!.db: %v", err)
 	}
 	defer file.Close()
 
 	val, err := ReadType("Int", file)
 	if err != nil {
-		return nil, fmt.Errorf("读取osuDB版本失败: %w", err)
+		return nil, i18n.Errorf(ErrCorrupt, "failed to read the osu!.db version: %v", err)
 	}
 	version := val.(int32)
 
@@ -377,12 +427,12 @@ func LoadOsuDBForHash(path string) ([]Difficulty2, error) {
 
 	_, err = ReadType("String", file)
 	if err != nil {
-		return nil, fmt.Errorf("读取文件头数据失败: %w", err)
+		return nil, i18n.Errorf(ErrCorrupt, "failed to read the file header: %v", err)
 	}
 
 	val, err = ReadType("Int", file)
 	if err != nil {
-		return nil, fmt.Errorf("读取文件头数据失败: %w", err)
+		return nil, i18n.Errorf(ErrCorrupt, "failed to read the file header: %v", err)
 	}
 	num_maps := val.(int32)
 
@@ -391,7 +441,7 @@ func LoadOsuDBForHash(path string) ([]Difficulty2, error) {
 	for i := 0; i < int(num_maps); i++ {
 		beatmap, err := ParseBeatmapForHash(file, version)
 		if err != nil {
-			return nil, fmt.Errorf("解析谱面失败: %w", err)
+			return nil, i18n.Errorf(ErrCorrupt, "failed to parse a beatmap: %v", err)
 		}
 		beatmaps = append(beatmaps, *beatmap)
 	}
@@ -0,0 +1,101 @@
+package db
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"hash/crc32"
+	"io"
+	"strings"
+	"unicode"
+
+	"OsuCollectionTab/i18n"
+)
+
+// ErrShareCode 分享码无法解析
+var ErrShareCode = errors.New("invalid share code")
+
+// 分享码格式常量
+const (
+	// ShareCodePrefix 分享码的前缀，其中的数字为格式版本
+	ShareCodePrefix = "oct1:"
+	// shareCodeMaxSize 解压后内容的上限，防止恶意分享码占用过多内存
+	shareCodeMaxSize = 16 << 20
+)
+
+// EncodeShareCode 把收藏夹编码为可以直接复制粘贴的分享码：
+// 前缀 + base64url(deflate(名称, 谱面数, 16字节哈希...) + CRC32)
+func EncodeShareCode(c Collection) (string, error) {
+	var raw bytes.Buffer
+	if err := writeDotNetString(&raw, c.Name); err != nil {
+		return "", err
+	}
+	raw.Write(binary.AppendUvarint(nil, uint64(len(c.Hashes))))
+	for _, hash := range c.Hashes {
+		b, err := hex.DecodeString(hash)
+		if err != nil || len(b) != 16 {
+			return "", i18n.Errorf(nil, "invalid beatmap hash %q", hash)
+		}
+		raw.Write(b)
+	}
+
+	var packed bytes.Buffer
+	zw, err := flate.NewWriter(&packed, flate.BestCompression)
+	if err != nil {
+		return "", err
+	}
+	if _, err := zw.Write(raw.Bytes()); err != nil {
+		return "", err
+	}
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+	packed.Write(binary.BigEndian.AppendUint32(nil, crc32.ChecksumIEEE(raw.Bytes())))
+
+	return ShareCodePrefix + base64.RawURLEncoding.EncodeToString(packed.Bytes()), nil
+}
+
+// DecodeShareCode 解析EncodeShareCode生成的分享码，忽略其中的空白字符
+// (聊天软件可能会把长分享码折行)，哈希统一为小写
+func DecodeShareCode(code string) (Collection, error) {
+	code = strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, code)
+	if !strings.HasPrefix(code, ShareCodePrefix) {
+		return Collection{}, i18n.Errorf(ErrShareCode, "share codes start with %s", ShareCodePrefix)
+	}
+
+	packed, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(code, ShareCodePrefix))
+	if err != nil || len(packed) < 4 {
+		return Collection{}, i18n.Errorf(ErrShareCode, "the share code is incomplete or damaged")
+	}
+	checksum := binary.BigEndian.Uint32(packed[len(packed)-4:])
+
+	raw, err := io.ReadAll(io.LimitReader(flate.NewReader(bytes.NewReader(packed[:len(packed)-4])), shareCodeMaxSize+1))
+	if err != nil || len(raw) > shareCodeMaxSize || crc32.ChecksumIEEE(raw) != checksum {
+		return Collection{}, i18n.Errorf(ErrShareCode, "the share code is incomplete or damaged")
+	}
+
+	or := &osdbReader{r: bufio.NewReader(bytes.NewReader(raw))}
+	c := Collection{Name: or.string()}
+	count, err := binary.ReadUvarint(or.r)
+	if or.err != nil || err != nil || count > uint64(len(raw))/16 {
+		return Collection{}, i18n.Errorf(ErrShareCode, "the share code is incomplete or damaged")
+	}
+	c.Hashes = make([]string, 0, count)
+	buf := make([]byte, 16)
+	for i := uint64(0); i < count; i++ {
+		if _, err := io.ReadFull(or.r, buf); err != nil {
+			return Collection{}, i18n.Errorf(ErrShareCode, "the share code is incomplete or damaged")
+		}
+		c.Hashes = append(c.Hashes, hex.EncodeToString(buf))
+	}
+	return c, nil
+}
@@ -0,0 +1,89 @@
+package db
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"OsuCollectionTab/i18n"
+)
+
+// 快照读取的重试参数
+const (
+	SnapshotAttempts   = 3
+	SnapshotRetryDelay = 500 * time.Millisecond
+)
+
+// ErrChanged 数据库在多次读取期间一直在被改写
+var ErrChanged = errors.New("database changed while reading")
+
+// ReadStable 先把path复制到临时文件再用parse解析，避免读到osu!正在写入的数据库。
+// 复制期间文件发生变化或解析失败时重试，文件未变且解析失败时直接返回错误
+func ReadStable[T any](path string, parse func(path string) (T, error)) (T, error) {
+	var zero T
+	var lastErr error
+	for attempt := 0; attempt < SnapshotAttempts; attempt++ {
+		if attempt > 0 {
+			slog.Debug("Reading database again", "path", path, "attempt", attempt+1, "err", lastErr)
+			time.Sleep(SnapshotRetryDelay)
+		}
+
+		before, err := os.Stat(path)
+		if err != nil {
+			return zero, i18n.Errorf(ErrOpen, "cannot open %s: %v", filepath.Base(path), err)
+		}
+
+		tmpPath, err := snapshot(path)
+		if err != nil {
+			return zero, err
+		}
+		result, parseErr := parse(tmpPath)
+		os.Remove(tmpPath)
+
+		after, err := os.Stat(path)
+		if err != nil {
+			return zero, i18n.Errorf(ErrOpen, "cannot open %s: %v", filepath.Base(path), err)
+		}
+		changed := !after.ModTime().Equal(before.ModTime()) || after.Size() != before.Size()
+
+		switch {
+		case changed:
+			lastErr = i18n.Errorf(ErrChanged, "%s changed while it was being read", filepath.Base(path))
+		case parseErr != nil:
+			// 复制到的内容是一致的，只可能是文件本身损坏或写入尚未完成
+			lastErr = parseErr
+			if !errors.Is(parseErr, ErrCorrupt) {
+				return zero, parseErr
+			}
+		default:
+			return result, nil
+		}
+	}
+	return zero, lastErr
+}
+
+// snapshot 把path复制到临时目录，返回副本路径
+func snapshot(path string) (string, error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return "", i18n.Errorf(ErrOpen, "cannot open %s: %v", filepath.Base(path), err)
+	}
+	defer src.Close()
+
+	dst, err := os.CreateTemp("", "osu-collection-tab-*-"+filepath.Base(path))
+	if err != nil {
+		return "", i18n.Errorf(nil, "failed to create a snapshot of %s: %v", filepath.Base(path), err)
+	}
+	_, err = io.Copy(dst, src)
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(dst.Name())
+		return "", i18n.Errorf(nil, "failed to create a snapshot of %s: %v", filepath.Base(path), err)
+	}
+	return dst.Name(), nil
+}
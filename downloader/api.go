@@ -0,0 +1,199 @@
+package downloader
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"OsuCollectionTab/i18n"
+	"OsuCollectionTab/secret"
+)
+
+var (
+	ErrNoToken  = errors.New("no osu! API token configured")
+	ErrNotFound = errors.New("beatmap not found")
+	ErrHTTP     = errors.New("osu! API request failed")
+	ErrDecode   = errors.New("invalid osu! API response")
+)
+
+// UnresolvedReason explains why a hash could not be mapped to a beatmap set.
+type UnresolvedReason string
+
+const (
+	ReasonNoToken  UnresolvedReason = "no token"
+	ReasonNotFound UnresolvedReason = "not found"
+	ReasonHTTP     UnresolvedReason = "http error"
+	ReasonDecode   UnresolvedReason = "decode error"
+)
+
+// Unresolved is a beatmap hash whose set ID lookup failed.
+type Unresolved struct {
+	Hash   string
+	Reason UnresolvedReason
+	Err    error
+}
+
+// ReasonFor classifies a LookupSetID error.
+func ReasonFor(err error) UnresolvedReason {
+	switch {
+	case errors.Is(err, ErrNoToken):
+		return ReasonNoToken
+	case errors.Is(err, ErrNotFound):
+		return ReasonNotFound
+	case errors.Is(err, ErrDecode):
+		return ReasonDecode
+	default:
+		return ReasonHTTP
+	}
+}
+
+// GetSetIDFromAPI returns the set ID of the beatmap with the given MD5 hash,
+// or 0 if it could not be resolved.
+func (d *Downloader) GetSetIDFromAPI(md5 string) int64 {
+	setID, err := d.LookupSetID(md5)
+	if err != nil {
+		return 0
+	}
+	return setID
+}
+
+// LookupSetID resolves an MD5 hash to its beatmap set ID, from the known set
+// IDs if possible and otherwise through the API. Failures wrap one of
+// ErrNoToken, ErrNotFound, ErrHTTP or ErrDecode and never contain the token.
+func (d *Downloader) LookupSetID(md5 string) (int64, error) {
+	if setID, ok := d.knownSetIDs[md5]; ok && setID > 0 {
+		return setID, nil
+	}
+	setID, err := d.lookupSetID(md5)
+	return setID, secret.Error(err)
+}
+
+// DefaultAPIURL is the base URL of the legacy osu! API.
+const DefaultAPIURL = "https://osu.ppy.sh/api"
+
+// SetAPIURL changes the osu! API base URL; an empty URL keeps the current one.
+func (d *Downloader) SetAPIURL(base string) {
+	if base != "" {
+		d.apiBase = strings.TrimRight(base, "/")
+	}
+}
+
+// apiURL builds a legacy API request URL. It contains the token, so it must
+// only reach logs and errors through secret.Redact.
+func (d *Downloader) apiURL(endpoint string, params url.Values) string {
+	params.Set("k", d.apiToken)
+	return d.apiBase + "/" + endpoint + "?" + params.Encode()
+}
+
+func (d *Downloader) lookupSetID(md5 string) (int64, error) {
+	beatmaps, err := d.getBeatmaps(url.Values{"h": {md5}})
+	if err != nil {
+		return 0, err
+	}
+
+	setID, err := strconv.ParseInt(beatmaps[0].SetID, 10, 64)
+	if err != nil {
+		return 0, i18n.Errorf(ErrDecode, "invalid osu! API response: beatmapset_id %q", beatmaps[0].SetID)
+	}
+
+	return setID, nil
+}
+
+// BeatmapRef is the hash and set of a beatmap (difficulty).
+type BeatmapRef struct {
+	Hash  string
+	SetID int64
+}
+
+// LookupBeatmap resolves a beatmap (difficulty) ID to its MD5 hash and set
+// ID. Failures are classified like those of LookupSetID.
+func (d *Downloader) LookupBeatmap(beatmapID int64) (BeatmapRef, error) {
+	ref, err := d.lookupBeatmap(beatmapID)
+	return ref, secret.Error(err)
+}
+
+// LookupBeatmapHash resolves a beatmap (difficulty) ID to its MD5 hash.
+func (d *Downloader) LookupBeatmapHash(beatmapID int64) (string, error) {
+	ref, err := d.LookupBeatmap(beatmapID)
+	return ref.Hash, err
+}
+
+func (d *Downloader) lookupBeatmap(beatmapID int64) (BeatmapRef, error) {
+	beatmaps, err := d.getBeatmaps(url.Values{"b": {strconv.FormatInt(beatmapID, 10)}})
+	if err != nil {
+		return BeatmapRef{}, err
+	}
+	if beatmaps[0].MD5 == "" {
+		return BeatmapRef{}, i18n.Errorf(ErrDecode, "invalid osu! API response: missing file_md5")
+	}
+	setID, err := strconv.ParseInt(beatmaps[0].SetID, 10, 64)
+	if err != nil {
+		return BeatmapRef{}, i18n.Errorf(ErrDecode, "invalid osu! API response: beatmapset_id %q", beatmaps[0].SetID)
+	}
+	return BeatmapRef{Hash: strings.ToLower(beatmaps[0].MD5), SetID: setID}, nil
+}
+
+// apiBeatmap is one entry of a get_beatmaps response. The legacy API encodes
+// numbers as strings.
+type apiBeatmap struct {
+	BeatmapID string `json:"beatmap_id"`
+	SetID     string `json:"beatmapset_id"`
+	MD5       string `json:"file_md5"`
+}
+
+// getBeatmaps queries get_beatmaps and fails with ErrNotFound when nothing
+// matches, so callers can rely on at least one result.
+func (d *Downloader) getBeatmaps(params url.Values) ([]apiBeatmap, error) {
+	if d.apiToken == "" {
+		return nil, i18n.Errorf(ErrNoToken, "no osu! API token configured")
+	}
+
+	// v2 API 需要 Bearer Token
+	// req, err := http.NewRequest("GET", fmt.Sprintf("https://osu.ppy.sh/api/v2/beatmaps/lookup?checksum=%s", md5), nil)
+	// if err != nil {
+	// 	return 0
+	// }
+	// req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", d.apiToken))
+
+	// v1 只需要固定的 Token
+	req, err := http.NewRequest("GET", d.apiURL("get_beatmaps", params), nil)
+	if err != nil {
+		return nil, i18n.Errorf(ErrHTTP, "osu! API request failed: %v", err)
+	}
+
+	resp, err := d.apiClient.Do(req)
+	if err != nil {
+		return nil, i18n.Errorf(ErrHTTP, "osu! API request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, i18n.Errorf(ErrHTTP, "osu! API request failed: HTTP %d", resp.StatusCode)
+	}
+
+	var response []apiBeatmap
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, i18n.Errorf(ErrDecode, "invalid osu! API response: %v", err)
+	}
+
+	if len(response) == 0 {
+		return nil, i18n.Errorf(ErrNotFound, "beatmap not found")
+	}
+	return response, nil
+}
+
+func (d *Downloader) recordUnresolved(hash string, err error) {
+	d.unresolvedMu.Lock()
+	defer d.unresolvedMu.Unlock()
+	d.unresolved = append(d.unresolved, Unresolved{Hash: hash, Reason: ReasonFor(err), Err: err})
+}
+
+// Unresolved returns the hashes ResolveSetIDs could not map to a set so far.
+func (d *Downloader) Unresolved() []Unresolved {
+	d.unresolvedMu.Lock()
+	defer d.unresolvedMu.Unlock()
+	return append([]Unresolved(nil), d.unresolved...)
+}
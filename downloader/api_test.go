@@ -0,0 +1,145 @@
+package downloader
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// testToken contains characters that are escaped in query strings, so that
+// both forms of it are checked.
+const testToken = "t3st-t0ken+/="
+
+const testHash = "0123456789abcdef0123456789abcdef"
+
+// newTestDownloader returns a downloader whose osu! API and osu!collector
+// site are both served by handler, under /api/ and /api/collections/.
+func newTestDownloader(t *testing.T, handler http.HandlerFunc) *Downloader {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	d, err := NewDownloader(t.TempDir(), "", 1, 0, testToken, TypeFull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.SetAPIURL(srv.URL + "/api")
+	d.SetOsuCollectorURL(srv.URL + "/")
+	return d
+}
+
+// assertNoToken fails if s contains the test token in plain or escaped form.
+func assertNoToken(t *testing.T, what, s string) {
+	t.Helper()
+	for _, leak := range []string{testToken, url.QueryEscape(testToken)} {
+		if strings.Contains(s, leak) {
+			t.Errorf("%s contains the token: %q", what, s)
+		}
+	}
+}
+
+// echoStatus answers with status and the request URL, token included, as a
+// misbehaving server might.
+func echoStatus(status int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		fmt.Fprintf(w, "error for %s", r.URL)
+	}
+}
+
+func closeConnection(w http.ResponseWriter, r *http.Request) {
+	conn, _, err := w.(http.Hijacker).Hijack()
+	if err == nil {
+		conn.Close()
+	}
+}
+
+func TestAPIErrorsAreRedacted(t *testing.T) {
+	calls := []struct {
+		name string
+		call func(d *Downloader) error
+	}{
+		{"LookupSetID", func(d *Downloader) error {
+			_, err := d.LookupSetID(testHash)
+			return err
+		}},
+		{"LookupBeatmap", func(d *Downloader) error {
+			_, err := d.LookupBeatmap(1)
+			return err
+		}},
+		{"CheckToken", func(d *Downloader) error {
+			return d.CheckToken()
+		}},
+		{"Unresolved", func(d *Downloader) error {
+			d.ResolveAll(map[string]struct{}{testHash: {}})
+			unresolved := d.Unresolved()
+			if len(unresolved) != 1 {
+				return nil
+			}
+			return unresolved[0].Err
+		}},
+	}
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		want    error
+	}{
+		{"server error", echoStatus(http.StatusInternalServerError), ErrHTTP},
+		{"unauthorized", echoStatus(http.StatusUnauthorized), ErrHTTP},
+		{"bad JSON", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `[{"beatmapset_id": %s`, r.URL.Query().Get("k"))
+		}, ErrDecode},
+		{"closed connection", closeConnection, ErrHTTP},
+	}
+
+	for _, tt := range tests {
+		for _, c := range calls {
+			t.Run(tt.name+"/"+c.name, func(t *testing.T) {
+				err := c.call(newTestDownloader(t, tt.handler))
+				if err == nil {
+					t.Fatal("no error")
+				}
+				if !errors.Is(err, tt.want) {
+					t.Errorf("error %q does not wrap %q", err, tt.want)
+				}
+				assertNoToken(t, "error", err.Error())
+			})
+		}
+	}
+}
+
+func TestLookupNotFound(t *testing.T) {
+	d := newTestDownloader(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "[]")
+	})
+
+	if _, err := d.LookupSetID(testHash); !errors.Is(err, ErrNotFound) {
+		t.Errorf("LookupSetID error = %v, want ErrNotFound", err)
+	}
+	if _, err := d.LookupBeatmap(1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("LookupBeatmap error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestLookupBeatmap(t *testing.T) {
+	d := newTestDownloader(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/get_beatmaps" || r.URL.Query().Get("b") != "42" || r.URL.Query().Get("k") != testToken {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `[{"beatmap_id": "42", "beatmapset_id": "7", "file_md5": "0123456789ABCDEF0123456789ABCDEF"}]`)
+	})
+
+	ref, err := d.LookupBeatmap(42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ref.SetID != 7 || ref.Hash != testHash {
+		t.Errorf("LookupBeatmap(42) = %+v, want set 7 and hash %s", ref, testHash)
+	}
+}
@@ -0,0 +1,66 @@
+package downloader
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"OsuCollectionTab/i18n"
+	"OsuCollectionTab/secret"
+)
+
+// CheckToken verifies the API token with a minimal lookup.
+func (d *Downloader) CheckToken() error {
+	return secret.Error(d.checkToken())
+}
+
+func (d *Downloader) checkToken() error {
+	if d.apiToken == "" {
+		return i18n.Errorf(ErrNoToken, "no osu! API token configured")
+	}
+
+	req, err := http.NewRequest("GET", d.apiURL("get_beatmaps", url.Values{"limit": {"1"}}), nil)
+	if err != nil {
+		return i18n.Errorf(ErrHTTP, "osu! API request failed: %v", err)
+	}
+
+	resp, err := d.apiClient.Do(req)
+	if err != nil {
+		return i18n.Errorf(ErrHTTP, "osu! API request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return i18n.Errorf(ErrHTTP, "the osu! API token was rejected")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return i18n.Errorf(ErrHTTP, "osu! API request failed: HTTP %d", resp.StatusCode)
+	}
+
+	var response []json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return i18n.Errorf(ErrDecode, "invalid osu! API response: %v", err)
+	}
+	return nil
+}
+
+// CheckMirror verifies that a mirror's host answers HTTP requests through the
+// configured proxy. Any HTTP status counts as reachable.
+func (d *Downloader) CheckMirror(mirror string) error {
+	u, err := url.Parse(mirror)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("HEAD", u.Scheme+"://"+u.Host+"/", nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := d.apiClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
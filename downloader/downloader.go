@@ -2,9 +2,10 @@ package downloader
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"os"
@@ -14,33 +15,120 @@ import (
 	"sync"
 	"time"
 
+	"OsuCollectionTab/i18n"
+	"OsuCollectionTab/secret"
+
 	"golang.org/x/sync/semaphore"
 )
 
+// DownloadType selects which package variant the mirror serves.
+type DownloadType string
+
+const (
+	TypeFull    DownloadType = "full"
+	TypeNoVideo DownloadType = "novideo"
+	TypeMini    DownloadType = "mini"
+)
+
+// DownloadTypes lists every valid DownloadType.
+var DownloadTypes = []DownloadType{TypeFull, TypeNoVideo, TypeMini}
+
+var (
+	ErrInvalidType  = errors.New("invalid download type")
+	ErrInvalidProxy = errors.New("invalid proxy URL")
+	ErrDownload     = errors.New("download failed")
+)
+
+// Valid reports whether t is one of the known download types.
+func (t DownloadType) Valid() bool {
+	for _, known := range DownloadTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseDownloadType parses a download type name, ignoring case and spaces.
+func ParseDownloadType(s string) (DownloadType, error) {
+	t := DownloadType(strings.ToLower(strings.TrimSpace(s)))
+	if !t.Valid() {
+		return "", i18n.Errorf(ErrInvalidType, "invalid download type %q (want full, novideo or mini)", s)
+	}
+	return t, nil
+}
+
+// DefaultMirror is the Sayo download endpoint; the type and set ID are appended.
+// Mirrors may instead contain {type} and {id} placeholders.
+const DefaultMirror = "https://dl.sayobot.cn/beatmaps/download"
+
+// Default timeouts of a single download and a single API request.
+const (
+	DefaultTimeout    = 120 * time.Second
+	DefaultAPITimeout = 30 * time.Second
+)
+
 type Downloader struct {
 	songsDir     string
 	proxy        string
 	workers      int
 	delay        time.Duration
 	apiToken     string
-	downloadType string
+	downloadType DownloadType
+	mirrors      []string
+	collectorURL string
+	apiBase      string
 	client       *http.Client
+	apiClient    *http.Client
+
+	rateMu       sync.Mutex
+	lastDownload time.Time
+
+	unresolvedMu sync.Mutex
+	unresolved   []Unresolved
+
+	knownSetIDs map[string]int64
+
+	wantedMu sync.Mutex
+	wanted   map[int64]map[string]struct{}
 }
 
-func NewDownloader(songsDir, proxy string, workers int, delay time.Duration, apiToken, downloadType string) *Downloader {
+// ParseProxy parses an HTTP proxy URL. It must have a scheme and a host, so
+// that a typo like "127.0.0.1:7890" is reported instead of being ignored.
+func ParseProxy(proxy string) (*url.URL, error) {
+	u, err := url.Parse(proxy)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, i18n.Errorf(ErrInvalidProxy, "invalid proxy URL: %s", proxy)
+	}
+	return u, nil
+}
+
+// NewDownloader creates a downloader. An empty proxy uses the system proxy
+// environment variables; an invalid one fails with ErrInvalidProxy.
+func NewDownloader(songsDir, proxy string, workers int, delay time.Duration, apiToken string, downloadType DownloadType) (*Downloader, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if proxy != "" {
+		proxyURL, err := ParseProxy(proxy)
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
 	client := &http.Client{
-		Timeout: 120 * time.Second,
+		Transport: transport,
+		Timeout:   DefaultTimeout,
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
 			return nil
 		},
 	}
-
-	if proxy != "" {
-		client.Transport = &http.Transport{
-			Proxy: http.ProxyFromEnvironment,
-		}
+	apiClient := &http.Client{
+		Transport: transport,
+		Timeout:   DefaultAPITimeout,
 	}
 
+	secret.Register(apiToken)
+
 	return &Downloader{
 		songsDir:     songsDir,
 		proxy:        proxy,
@@ -48,112 +136,335 @@ func NewDownloader(songsDir, proxy string, workers int, delay time.Duration, api
 		delay:        delay,
 		apiToken:     apiToken,
 		downloadType: downloadType,
+		mirrors:      []string{DefaultMirror},
+		collectorURL: DefaultOsuCollectorURL,
+		apiBase:      DefaultAPIURL,
 		client:       client,
+		apiClient:    apiClient,
+	}, nil
+}
+
+// SetDownloadType changes the package type requested from the mirror.
+func (d *Downloader) SetDownloadType(downloadType DownloadType) error {
+	if !downloadType.Valid() {
+		return i18n.Errorf(ErrInvalidType, "invalid download type %q (want full, novideo or mini)", downloadType)
+	}
+	d.downloadType = downloadType
+	return nil
+}
+
+// DownloadType returns the package type requested from the mirror.
+func (d *Downloader) DownloadType() DownloadType {
+	return d.downloadType
+}
+
+// SetMirrors changes the mirrors sets are downloaded from. They are tried in
+// order until one succeeds; an empty list keeps the current mirrors.
+func (d *Downloader) SetMirrors(mirrors []string) {
+	if len(mirrors) == 0 {
+		return
+	}
+	d.mirrors = make([]string, len(mirrors))
+	for i, m := range mirrors {
+		d.mirrors[i] = strings.TrimRight(m, "/")
 	}
 }
 
-func (d *Downloader) SetDownloadType(downloadType string) {
-    d.downloadType = downloadType
+// Mirrors returns the mirrors sets are downloaded from.
+func (d *Downloader) Mirrors() []string {
+	return append([]string(nil), d.mirrors...)
+}
+
+// SetTimeouts changes the per-request timeouts of downloads and API lookups.
+// Zero values keep the current timeout.
+func (d *Downloader) SetTimeouts(download, api time.Duration) {
+	if download > 0 {
+		d.client.Timeout = download
+	}
+	if api > 0 {
+		d.apiClient.Timeout = api
+	}
+}
+
+// SetKnownSetIDs gives the set IDs of hashes that are already known, e.g.
+// from a .osdb file. LookupSetID returns them without querying the API. It
+// must be called before resolving starts.
+func (d *Downloader) SetKnownSetIDs(known map[string]int64) {
+	d.knownSetIDs = known
+}
+
+// Stats summarizes a finished download run.
+type Stats struct {
+	Sets       int
+	Downloaded int
+	Skipped    int // already present in the Songs folder
+	Failed     int
 }
 
+// ResolveSetIDs looks up the beatmap set of every hash concurrently and streams
+// the set ID of each hash as soon as it is known, so a set with several
+// wanted difficulties is sent once per difficulty; DownloadStream checks it
+// again each time. The returned channel is closed after the last lookup
+// finishes, or once ctx is done, which stops the lookups of a consumer that
+// gave up.
+func (d *Downloader) ResolveSetIDs(ctx context.Context, hashes map[string]struct{}) <-chan int64 {
+	out := make(chan int64, d.workers)
+
+	go func() {
+		d.resolveEach(ctx, hashes, func(_ string, setID int64) {
+			select {
+			case out <- setID:
+			case <-ctx.Done():
+			}
+		})
+		close(out)
+	}()
+
+	return out
+}
+
+// ResolveAll looks up every hash and returns the set ID of each resolved one.
+func (d *Downloader) ResolveAll(hashes map[string]struct{}) map[string]int64 {
+	var mu sync.Mutex
+	resolved := make(map[string]int64, len(hashes))
+
+	d.resolveEach(context.Background(), hashes, func(hash string, setID int64) {
+		mu.Lock()
+		resolved[hash] = setID
+		mu.Unlock()
+	})
+
+	return resolved
+}
+
+// resolveEach runs the lookups on d.workers goroutines and calls fn for every
+// hash that resolved. Failures are recorded in d.unresolved. No further
+// lookups start once ctx is done.
+func (d *Downloader) resolveEach(ctx context.Context, hashes map[string]struct{}, fn func(hash string, setID int64)) {
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+
+	for i := 0; i < d.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for hash := range jobs {
+				setID, err := d.LookupSetID(hash)
+				if err != nil {
+					d.recordUnresolved(hash, err)
+					continue
+				}
+				d.Want(setID, hash)
+				fn(hash, setID)
+			}
+		}()
+	}
+
+feed:
+	for hash := range hashes {
+		select {
+		case jobs <- hash:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// DownloadAll downloads every set in setIDs and blocks until all are done.
+// It fails with ErrDownload when any set could not be downloaded.
 func (d *Downloader) DownloadAll(setIDs map[int64]struct{}) error {
+	ch := make(chan int64, len(setIDs))
+	for id := range setIDs {
+		ch <- id
+	}
+	close(ch)
+
+	stats, err := d.DownloadStream(ch)
+	if err != nil {
+		return err
+	}
+	if stats.Failed > 0 {
+		return i18n.Errorf(ErrDownload, "%d of %d beatmapsets failed to download", stats.Failed, stats.Sets)
+	}
+	return nil
+}
+
+// DownloadStream downloads sets as they arrive on setIDs until the channel is
+// closed, so downloads can start while the producer is still resolving. A set
+// may arrive more than once: one skipped as complete is checked again, as
+// more of its wanted difficulties may have been recorded since.
+func (d *Downloader) DownloadStream(setIDs <-chan int64) (Stats, error) {
+	var stats Stats
 	if err := os.MkdirAll(d.songsDir, 0755); err != nil {
-		return fmt.Errorf("Failed to create directory: %v", err)
+		return stats, i18n.Errorf(nil, "failed to create directory: %v", err)
+	}
+
+	existing, err := ExistingSets(d.songsDir)
+	if err != nil {
+		return stats, i18n.Errorf(nil, "failed to scan %s: %v", d.songsDir, err)
 	}
 
 	sem := semaphore.NewWeighted(int64(d.workers))
 	ctx := context.Background()
-	var wg sync.WaitGroup
-	var lastDownload time.Time
-
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+
+	// skipped tells, for every set seen so far, whether it was skipped as
+	// complete rather than downloaded.
+	skipped := make(map[int64]bool)
 	for setID := range setIDs {
-		wg.Add(1)
+		wasSkipped, seen := skipped[setID]
+		if seen && !wasSkipped {
+			continue
+		}
+		if !seen {
+			stats.Sets++
+		}
+		if paths, ok := existing[setID]; ok {
+			missing := d.missingFrom(setID, paths)
+			if missing == 0 {
+				if !seen {
+					slog.Info("Skipping set already in the Songs folder", "set_id", setID, "dir", d.songsDir)
+					mu.Lock()
+					stats.Skipped++
+					mu.Unlock()
+				}
+				skipped[setID] = true
+				continue
+			}
+			if seen {
+				mu.Lock()
+				stats.Skipped--
+				mu.Unlock()
+			}
+			slog.Info("Set in the Songs folder lacks wanted difficulties, downloading it again", "set_id", setID, "missing", missing)
+		}
+		skipped[setID] = false
+
 		if err := sem.Acquire(ctx, 1); err != nil {
-			return err
+			wg.Wait()
+			return stats, err
 		}
+		wg.Add(1)
 
 		go func(id int64) {
 			defer sem.Release(1)
 			defer wg.Done()
 
 			// 控制下载速率
-			if elapsed := time.Since(lastDownload); elapsed < d.delay {
-				time.Sleep(d.delay - elapsed)
-			}
+			d.throttle()
 
 			err := d.downloadBeatmapSet(id)
+
+			mu.Lock()
+			defer mu.Unlock()
 			if err != nil {
-				fmt.Printf("Download failed for set %d: %v\n", id, err)
+				stats.Failed++
+				slog.Error("Download failed", "set_id", id, "err", err)
 			} else {
-				fmt.Printf("Downloaded set %d\n", id)
+				stats.Downloaded++
+				slog.Info("Downloaded set", "set_id", id)
 			}
-
-			lastDownload = time.Now()
 		}(setID)
 	}
 
 	wg.Wait()
-	return nil
+	return stats, nil
 }
 
-func (d *Downloader) downloadBeatmapSet(setID int64) error {
-	fmt.Printf("Downloading set %d\n", setID)
-	url := fmt.Sprintf("https://dl.sayobot.cn/beatmaps/download/%s/%d", d.downloadType, setID)
-	finalPath := filepath.Join(d.songsDir, fmt.Sprintf("%d.osz", setID))
-	return d.tryDownload(url, finalPath)
+// Want records that the difficulties with the given hashes are wanted from a
+// set. Sets found in the Songs folder are only skipped when they contain all
+// of them, so that a set extracted before a map update is downloaded again.
+// Lookups through ResolveSetIDs and ResolveAll record their hashes already.
+func (d *Downloader) Want(setID int64, hashes ...string) {
+	d.wantedMu.Lock()
+	defer d.wantedMu.Unlock()
+	if d.wanted == nil {
+		d.wanted = make(map[int64]map[string]struct{})
+	}
+	if d.wanted[setID] == nil {
+		d.wanted[setID] = make(map[string]struct{})
+	}
+	for _, hash := range hashes {
+		d.wanted[setID][strings.ToLower(hash)] = struct{}{}
+	}
 }
 
-func (d *Downloader) GetSetIDFromAPI(md5 string) int64 {
-	if d.apiToken == "" {
+// missingFrom counts the wanted difficulties of a set that none of paths, its
+// folders and archives in the Songs folder, contains. Without wanted hashes
+// the set counts as complete; unreadable paths count as lacking everything.
+func (d *Downloader) missingFrom(setID int64, paths []string) int {
+	d.wantedMu.Lock()
+	wanted := d.wanted[setID]
+	d.wantedMu.Unlock()
+	if len(wanted) == 0 {
 		return 0
 	}
 
-	// v2 API 需要 Bearer Token
-	// req, err := http.NewRequest("GET", fmt.Sprintf("https://osu.ppy.sh/api/v2/beatmaps/lookup?checksum=%s", md5), nil)
-	// if err != nil {
-	// 	return 0
-	// }
-	// req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", d.apiToken))
-
-	// v1 只需要固定的 Token
-	req, err := http.NewRequest("GET", fmt.Sprintf("https://osu.ppy.sh/api/get_beatmaps?k=%s&h=%s", d.apiToken, md5), nil)
-	if err != nil {
-		return 0
+	found := make(map[string]struct{})
+	for _, path := range paths {
+		hashes, err := SetHashes(path)
+		if err != nil {
+			slog.Debug("Failed to read set from the Songs folder", "path", path, "err", err)
+			continue
+		}
+		for hash := range hashes {
+			found[hash] = struct{}{}
+		}
 	}
 
-	resp, err := d.client.Do(req)
-	if err != nil {
-		return 0
+	missing := 0
+	for hash := range wanted {
+		if _, ok := found[hash]; !ok {
+			missing++
+		}
 	}
-	defer resp.Body.Close()
+	return missing
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return 0
-	}
+// throttle blocks until at least d.delay has passed since the previous
+// download was started by any worker.
+func (d *Downloader) throttle() {
+	d.rateMu.Lock()
+	defer d.rateMu.Unlock()
 
-	var response []struct {
-		SetID string `json:"beatmapset_id"`
+	if wait := d.delay - time.Since(d.lastDownload); wait > 0 {
+		time.Sleep(wait)
 	}
+	d.lastDownload = time.Now()
+}
 
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		fmt.Printf("Failed to decode JSON: %v\n", err)
-		return 0
-	}
+func (d *Downloader) downloadBeatmapSet(setID int64) error {
+	slog.Info("Downloading set", "set_id", setID)
+	finalPath := filepath.Join(d.songsDir, fmt.Sprintf("%d.osz", setID))
 
-	if len(response) == 0 {
-		return 0
+	var errs []error
+	for _, mirror := range d.mirrors {
+		err := d.tryDownload(mirrorURL(mirror, d.downloadType, setID), finalPath)
+		if err == nil {
+			return nil
+		}
+		slog.Debug("Mirror failed", "set_id", setID, "mirror", mirror, "err", err)
+		errs = append(errs, i18n.Errorf(ErrDownload, "%s: %v", mirror, err))
 	}
+	return errors.Join(errs...)
+}
 
-	setID, err := strconv.ParseInt(response[0].SetID, 10, 64)
-	if err != nil {
-		return 0
+// mirrorURL builds the download URL of a set on a mirror.
+func mirrorURL(mirror string, downloadType DownloadType, setID int64) string {
+	if strings.Contains(mirror, "{id}") {
+		r := strings.NewReplacer("{type}", string(downloadType), "{id}", strconv.FormatInt(setID, 10))
+		return r.Replace(mirror)
 	}
-
-	return setID
+	return fmt.Sprintf("%s/%s/%d", mirror, downloadType, setID)
 }
 
 func (d *Downloader) tryDownload(targetUrl, filePath string) error {
-	fmt.Printf("Downloading from %s\n", targetUrl)
+	slog.Debug("Downloading", "url", targetUrl)
 	req, err := http.NewRequest("GET", targetUrl, nil)
 	if err != nil {
 		return err
@@ -165,18 +476,18 @@ func (d *Downloader) tryDownload(targetUrl, filePath string) error {
 
 	resp, err := d.client.Do(req)
 	if err != nil {
-		return fmt.Errorf("request failed: %v", err)
+		return i18n.Errorf(nil, "request failed: %v", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+		return i18n.Errorf(nil, "HTTP %d: %s", resp.StatusCode, string(body))
 	}
 
 	contentType := resp.Header.Get("Content-Type")
 	if contentType != "application/octet-stream" && !strings.HasPrefix(contentType, "application/") {
-		return fmt.Errorf("Invalid content type: %s", contentType)
+		return i18n.Errorf(nil, "invalid content type: %s", contentType)
 	}
 
 	// Extract filename from Content-Disposition header
@@ -218,13 +529,13 @@ func (d *Downloader) tryDownload(targetUrl, filePath string) error {
 	tmpPath := filePath + ".tmp"
 	out, err := os.Create(tmpPath)
 	if err != nil {
-		fmt.Printf("Failed to create temp file: %v\n", err)
+		slog.Debug("Failed to create temp file", "path", tmpPath, "err", err)
 		return err
 	}
 
 	_, err = io.Copy(out, resp.Body)
 	if err != nil {
-		fmt.Printf("Failed to write to temp file: %v\n", err)
+		slog.Debug("Failed to write to temp file", "path", tmpPath, "err", err)
 		out.Close()
 		os.Remove(tmpPath)
 		return err
@@ -247,10 +558,10 @@ func (d *Downloader) tryDownload(targetUrl, filePath string) error {
 		}
 
 		if attempts < 2 { // before the last attempt
-			fmt.Printf("Rename attempt %d failed: %v. Retrying after delay...\n", attempts+1, err)
+			slog.Warn("Rename failed, retrying after delay", "attempt", attempts+1, "err", err)
 			time.Sleep(500 * time.Millisecond) // Wait before retrying
 		} else {
-			return fmt.Errorf("failed to rename file after multiple attempts: %v", err)
+			return i18n.Errorf(nil, "failed to rename file after multiple attempts: %v", err)
 		}
 	}
 
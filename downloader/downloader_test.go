@@ -0,0 +1,161 @@
+package downloader
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// installedDifficulty is the only difficulty of set 100 in the Songs folder.
+const installedDifficulty = "osu file format v14\n[Metadata]\nVersion:Easy\n"
+
+// newSongsDownloader returns a downloader whose Songs folder holds set 100
+// with installedDifficulty, and which downloads from a mirror that records
+// the requested set IDs.
+func newSongsDownloader(t *testing.T) (*Downloader, func() []string) {
+	t.Helper()
+	var (
+		mu        sync.Mutex
+		requested []string
+	)
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requested = append(requested, r.URL.Path)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/octet-stream")
+		fmt.Fprint(w, "osz")
+	}))
+	t.Cleanup(mirror.Close)
+
+	d := newTestDownloader(t, http.NotFound)
+	d.SetMirrors([]string{mirror.URL + "/d/{id}"})
+
+	dir := filepath.Join(d.songsDir, "100 Artist - Title")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "easy.osu"), []byte(installedDifficulty), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return d, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), requested...)
+	}
+}
+
+func TestDownloadStreamSkipsCompleteSets(t *testing.T) {
+	d, requested := newSongsDownloader(t)
+	sum := md5.Sum([]byte(installedDifficulty))
+	d.Want(100, hex.EncodeToString(sum[:]))
+
+	stats, err := d.DownloadStream(sendAll(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats != (Stats{Sets: 1, Skipped: 1}) {
+		t.Errorf("stats = %+v, want one skipped set", stats)
+	}
+	if got := requested(); len(got) != 0 {
+		t.Errorf("mirror was asked for %v", got)
+	}
+}
+
+func TestDownloadStreamRechecksSkippedSets(t *testing.T) {
+	d, requested := newSongsDownloader(t)
+	sum := md5.Sum([]byte(installedDifficulty))
+
+	// Set 100 first arrives with its installed difficulty only, and again
+	// once a difficulty it lacks has resolved. Sending set 999 in between
+	// with an unbuffered channel ensures the first check is done by then.
+	ch := make(chan int64)
+	go func() {
+		d.Want(100, hex.EncodeToString(sum[:]))
+		ch <- 100
+		ch <- 999
+		d.Want(100, testHash)
+		ch <- 100
+		close(ch)
+	}()
+
+	stats, err := d.DownloadStream(ch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats != (Stats{Sets: 2, Downloaded: 2}) {
+		t.Errorf("stats = %+v, want two downloaded sets", stats)
+	}
+	if got := requested(); len(got) != 2 {
+		t.Errorf("mirror was asked for %v, want sets 100 and 999", got)
+	}
+}
+
+// sendAll returns a closed channel holding setIDs.
+func sendAll(setIDs ...int64) <-chan int64 {
+	ch := make(chan int64, len(setIDs))
+	for _, id := range setIDs {
+		ch <- id
+	}
+	close(ch)
+	return ch
+}
+
+func TestResolveSetIDsSendsEveryHash(t *testing.T) {
+	d := newTestDownloader(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `[{"beatmap_id": "1", "beatmapset_id": "100", "file_md5": %q}]`, r.URL.Query().Get("h"))
+	})
+	other := "fedcba9876543210fedcba9876543210"
+
+	var got []int64
+	for id := range d.ResolveSetIDs(context.Background(), map[string]struct{}{testHash: {}, other: {}}) {
+		got = append(got, id)
+	}
+	if len(got) != 2 || got[0] != 100 || got[1] != 100 {
+		t.Errorf("got set IDs %v, want set 100 once per hash", got)
+	}
+	if missing := d.missingFrom(100, nil); missing != 2 {
+		t.Errorf("set 100 lacks %d wanted difficulties, want 2", missing)
+	}
+}
+
+func TestResolveSetIDsStopsWhenCancelled(t *testing.T) {
+	var lookups atomic.Int32
+	d := newTestDownloader(t, func(w http.ResponseWriter, r *http.Request) {
+		lookups.Add(1)
+		fmt.Fprintf(w, `[{"beatmap_id": "1", "beatmapset_id": "%d", "file_md5": %q}]`, lookups.Load(), r.URL.Query().Get("h"))
+	})
+	hashes := make(map[string]struct{})
+	for i := 0; i < 50; i++ {
+		hashes[fmt.Sprintf("%032x", i)] = struct{}{}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := d.ResolveSetIDs(ctx, hashes)
+	<-ch
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		for range ch {
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the channel was not closed after cancelling")
+	}
+	if n := lookups.Load(); n >= int32(len(hashes)) {
+		t.Errorf("all %d hashes were looked up after cancelling", n)
+	}
+}
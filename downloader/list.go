@@ -0,0 +1,96 @@
+package downloader
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// EntryKind tells what an entry of a download list refers to.
+type EntryKind int
+
+const (
+	EntrySet     EntryKind = iota // a beatmap set ID
+	EntryBeatmap                  // a beatmap (difficulty) ID
+	EntryHash                     // a beatmap MD5 hash
+)
+
+// ListEntry is one beatmap or set named in a download list.
+type ListEntry struct {
+	Kind EntryKind
+	ID   int64  // set or beatmap ID
+	Hash string // lowercase MD5 hash for EntryHash
+	Text string // the text the entry was read from
+	Line int
+}
+
+var (
+	// A set link may name a difficulty after the mode, as in
+	// osu.ppy.sh/beatmapsets/123#osu/456; only the set is needed then.
+	setURLPattern     = regexp.MustCompile(`(?i)osu\.ppy\.sh/(?:beatmapsets|s)/(\d+)`)
+	beatmapURLPattern = regexp.MustCompile(`(?i)osu\.ppy\.sh/(?:beatmaps|b)/(\d+)`)
+	listHashPattern   = regexp.MustCompile(`^[0-9a-fA-F]{32}$`)
+	listIDPattern     = regexp.MustCompile(`^\d+$`)
+)
+
+// ParseList reads a download list: one entry per line, or several per line
+// as pasted from chat. Links and hashes are recognized anywhere in a line;
+// bare numbers only count on lines without them, so that numbers in titles
+// next to a link are ignored, and are read as set IDs or, with bareBeatmaps,
+// as beatmap IDs. Empty lines and lines starting with # are skipped. Lines
+// with nothing recognizable are returned as invalid.
+func ParseList(r io.Reader, bareBeatmaps bool) (entries []ListEntry, invalid []string, err error) {
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var found, bare []ListEntry
+		for _, token := range strings.FieldsFunc(line, isListSeparator) {
+			switch {
+			case setURLPattern.MatchString(token):
+				id, _ := strconv.ParseInt(setURLPattern.FindStringSubmatch(token)[1], 10, 64)
+				found = append(found, ListEntry{Kind: EntrySet, ID: id, Text: token, Line: n})
+			case beatmapURLPattern.MatchString(token):
+				id, _ := strconv.ParseInt(beatmapURLPattern.FindStringSubmatch(token)[1], 10, 64)
+				found = append(found, ListEntry{Kind: EntryBeatmap, ID: id, Text: token, Line: n})
+			case listHashPattern.MatchString(token):
+				found = append(found, ListEntry{Kind: EntryHash, Hash: strings.ToLower(token), Text: token, Line: n})
+			case listIDPattern.MatchString(token):
+				id, err := strconv.ParseInt(token, 10, 64)
+				if err != nil || id <= 0 {
+					continue
+				}
+				kind := EntrySet
+				if bareBeatmaps {
+					kind = EntryBeatmap
+				}
+				bare = append(bare, ListEntry{Kind: kind, ID: id, Text: token, Line: n})
+			}
+		}
+
+		switch {
+		case len(found) > 0:
+			entries = append(entries, found...)
+		case len(bare) > 0:
+			entries = append(entries, bare...)
+		default:
+			invalid = append(invalid, line)
+		}
+	}
+	return entries, invalid, scanner.Err()
+}
+
+// isListSeparator splits list lines into tokens. Brackets and commas separate
+// the links of chat messages such as "[https://osu.ppy.sh/b/1 Title]".
+func isListSeparator(r rune) bool {
+	switch r {
+	case ' ', '\t', ',', ';', '[', ']', '(', ')', '<', '>', '"', '\'':
+		return true
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package downloader
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"OsuCollectionTab/i18n"
+)
+
+// DefaultOsuCollectorURL is the osu!collector site collections are fetched from.
+const DefaultOsuCollectorURL = "https://osucollector.com"
+
+// CollectorBeatmap is a beatmap of an osu!collector collection.
+type CollectorBeatmap struct {
+	ID       int64  `json:"id"`
+	Checksum string `json:"checksum"`
+}
+
+// CollectorBeatmapset is a beatmap set of an osu!collector collection with
+// the beatmaps of it that are in the collection.
+type CollectorBeatmapset struct {
+	ID       int64              `json:"id"`
+	Beatmaps []CollectorBeatmap `json:"beatmaps"`
+}
+
+// CollectorCollection is a collection as returned by the osu!collector API.
+type CollectorCollection struct {
+	ID          int64                 `json:"id"`
+	Name        string                `json:"name"`
+	Beatmapsets []CollectorBeatmapset `json:"beatmapsets"`
+}
+
+// Hashes returns the lowercase MD5 hashes of the collection's beatmaps in
+// the order of the response.
+func (c *CollectorCollection) Hashes() []string {
+	var hashes []string
+	for _, set := range c.Beatmapsets {
+		for _, b := range set.Beatmaps {
+			if b.Checksum != "" {
+				hashes = append(hashes, strings.ToLower(b.Checksum))
+			}
+		}
+	}
+	return hashes
+}
+
+// SetIDs maps the hash of every beatmap to its set ID.
+func (c *CollectorCollection) SetIDs() map[string]int64 {
+	ids := make(map[string]int64)
+	for _, set := range c.Beatmapsets {
+		for _, b := range set.Beatmaps {
+			if b.Checksum != "" && set.ID > 0 {
+				ids[strings.ToLower(b.Checksum)] = set.ID
+			}
+		}
+	}
+	return ids
+}
+
+// SetOsuCollectorURL changes the osu!collector base URL; an empty URL keeps
+// the current one.
+func (d *Downloader) SetOsuCollectorURL(base string) {
+	if base != "" {
+		d.collectorURL = strings.TrimRight(base, "/")
+	}
+}
+
+// FetchOsuCollector fetches an osu!collector collection through the
+// configured proxy. Failures wrap ErrNotFound, ErrHTTP or ErrDecode.
+func (d *Downloader) FetchOsuCollector(id int64) (*CollectorCollection, error) {
+	req, err := http.NewRequest("GET", d.collectorURL+"/api/collections/"+strconv.FormatInt(id, 10), nil)
+	if err != nil {
+		return nil, i18n.Errorf(ErrHTTP, "osu!collector request failed: %v", err)
+	}
+
+	resp, err := d.apiClient.Do(req)
+	if err != nil {
+		return nil, i18n.Errorf(ErrHTTP, "osu!collector request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, i18n.Errorf(ErrNotFound, "osu!collector collection %d not found", id)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, i18n.Errorf(ErrHTTP, "osu!collector request failed: HTTP %d", resp.StatusCode)
+	}
+
+	var collection CollectorCollection
+	if err := json.NewDecoder(resp.Body).Decode(&collection); err != nil {
+		return nil, i18n.Errorf(ErrDecode, "invalid osu!collector response: %v", err)
+	}
+	return &collection, nil
+}
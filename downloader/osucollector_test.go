@@ -0,0 +1,76 @@
+package downloader
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestFetchOsuCollector(t *testing.T) {
+	d := newTestDownloader(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/collections/42" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{
+			"id": 42,
+			"name": "Fake Pool",
+			"uploader": {"username": "someone"},
+			"beatmapsets": [
+				{"id": 100, "beatmaps": [{"id": 1000, "checksum": "00000000000000000000000000ABC000"}]},
+				{"id": 900, "beatmaps": [
+					{"id": 9001, "checksum": "00000000000000000000000000fff001"},
+					{"id": 9002, "checksum": ""}
+				]}
+			]
+		}`)
+	})
+
+	c, err := d.FetchOsuCollector(42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.ID != 42 || c.Name != "Fake Pool" {
+		t.Errorf("got collection %d %q, want 42 \"Fake Pool\"", c.ID, c.Name)
+	}
+
+	wantHashes := []string{"00000000000000000000000000abc000", "00000000000000000000000000fff001"}
+	if got := c.Hashes(); !reflect.DeepEqual(got, wantHashes) {
+		t.Errorf("Hashes() = %v, want %v", got, wantHashes)
+	}
+	wantSets := map[string]int64{
+		"00000000000000000000000000abc000": 100,
+		"00000000000000000000000000fff001": 900,
+	}
+	if got := c.SetIDs(); !reflect.DeepEqual(got, wantSets) {
+		t.Errorf("SetIDs() = %v, want %v", got, wantSets)
+	}
+}
+
+func TestFetchOsuCollectorErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		want    error
+	}{
+		{"not found", http.NotFound, ErrNotFound},
+		{"server error", func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "down", http.StatusBadGateway)
+		}, ErrHTTP},
+		{"malformed JSON", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"id": 42, "beatmapsets": [`)
+		}, ErrDecode},
+		{"closed connection", closeConnection, ErrHTTP},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newTestDownloader(t, tt.handler).FetchOsuCollector(42)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package downloader
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sort"
+
+	"OsuCollectionTab/i18n"
+)
+
+var ErrInvalidPlan = errors.New("invalid download plan")
+
+// Plan is the reviewable result of a dry run: every set that would be
+// downloaded, the collection entries it satisfies and where it comes from.
+type Plan struct {
+	Mirrors    []string      `json:"mirrors"`
+	Type       DownloadType  `json:"type"`
+	Sets       []PlannedSet  `json:"sets"`
+	Unresolved []PlannedHash `json:"unresolved,omitempty"`
+}
+
+// PlannedSet is one beatmap set in a Plan.
+type PlannedSet struct {
+	SetID  int64         `json:"set_id"`
+	Hashes []PlannedHash `json:"hashes,omitempty"`
+}
+
+// PlannedHash is a missing collection entry and the collections listing it.
+type PlannedHash struct {
+	Hash        string   `json:"hash"`
+	Collections []string `json:"collections,omitempty"`
+	Reason      string   `json:"reason,omitempty"`
+}
+
+// BuildPlan groups resolved hashes by set. collectionsOf maps a hash to the
+// names of the collections containing it and may be nil.
+func (d *Downloader) BuildPlan(resolved map[string]int64, collectionsOf map[string][]string) *Plan {
+	plan := &Plan{Mirrors: d.Mirrors(), Type: d.downloadType}
+
+	bySet := make(map[int64][]PlannedHash)
+	for hash, setID := range resolved {
+		bySet[setID] = append(bySet[setID], PlannedHash{Hash: hash, Collections: collectionsOf[hash]})
+	}
+
+	for setID, hashes := range bySet {
+		sort.Slice(hashes, func(i, j int) bool { return hashes[i].Hash < hashes[j].Hash })
+		plan.Sets = append(plan.Sets, PlannedSet{SetID: setID, Hashes: hashes})
+	}
+	sort.Slice(plan.Sets, func(i, j int) bool { return plan.Sets[i].SetID < plan.Sets[j].SetID })
+
+	for _, u := range d.Unresolved() {
+		plan.Unresolved = append(plan.Unresolved, PlannedHash{
+			Hash:        u.Hash,
+			Collections: collectionsOf[u.Hash],
+			Reason:      string(u.Reason),
+		})
+	}
+	sort.Slice(plan.Unresolved, func(i, j int) bool { return plan.Unresolved[i].Hash < plan.Unresolved[j].Hash })
+
+	return plan
+}
+
+// SetIDs returns the distinct set IDs in the plan.
+func (p *Plan) SetIDs() map[int64]struct{} {
+	ids := make(map[int64]struct{}, len(p.Sets))
+	for _, s := range p.Sets {
+		ids[s.SetID] = struct{}{}
+	}
+	return ids
+}
+
+// WantPlan records the hashes of every set in the plan with Want.
+func (d *Downloader) WantPlan(p *Plan) {
+	for _, set := range p.Sets {
+		for _, h := range set.Hashes {
+			d.Want(set.SetID, h.Hash)
+		}
+	}
+}
+
+// SavePlan writes plan as indented JSON.
+func SavePlan(path string, plan *Plan) error {
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// LoadPlan reads a plan written by SavePlan, possibly edited by hand.
+func LoadPlan(path string) (*Plan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var plan Plan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, i18n.Errorf(ErrInvalidPlan, "invalid plan %s: %v", path, err)
+	}
+	if !plan.Type.Valid() {
+		return nil, i18n.Errorf(ErrInvalidPlan, "invalid plan %s: bad type %q", path, plan.Type)
+	}
+	for _, s := range plan.Sets {
+		if s.SetID <= 0 {
+			return nil, i18n.Errorf(ErrInvalidPlan, "invalid plan %s: bad set_id %d", path, s.SetID)
+		}
+	}
+	return &plan, nil
+}
@@ -0,0 +1,118 @@
+package downloader
+
+import (
+	"archive/zip"
+	"crypto/md5"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ExistingSets scans a Songs folder for sets that are already there, either
+// extracted ("123 Artist - Title") or as a downloaded but not yet imported
+// "123 Artist - Title.osz" / "123.osz", and returns the paths found for each
+// set ID. A missing folder yields an empty map.
+func ExistingSets(dir string) (map[int64][]string, error) {
+	ids := make(map[int64][]string)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ids, nil
+		}
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() {
+			if !strings.HasSuffix(strings.ToLower(name), ".osz") {
+				continue
+			}
+			name = name[:len(name)-len(".osz")]
+		}
+
+		end := strings.IndexFunc(name, func(r rune) bool { return r < '0' || r > '9' })
+		if end == -1 {
+			end = len(name)
+		} else if name[end] != ' ' {
+			continue
+		}
+		if id, err := strconv.ParseInt(name[:end], 10, 64); err == nil && id > 0 {
+			ids[id] = append(ids[id], filepath.Join(dir, entry.Name()))
+		}
+	}
+
+	return ids, nil
+}
+
+// SetHashes returns the MD5 hashes of the .osu files of an extracted set
+// folder or of a .osz archive, the hashes osu!.db and collections refer to.
+func SetHashes(path string) (map[string]struct{}, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return oszHashes(path)
+	}
+
+	hashes := make(map[string]struct{})
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(strings.ToLower(entry.Name()), ".osu") {
+			continue
+		}
+		f, err := os.Open(filepath.Join(path, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		hash, err := md5Hex(f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		hashes[hash] = struct{}{}
+	}
+	return hashes, nil
+}
+
+func oszHashes(path string) (map[string]struct{}, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	hashes := make(map[string]struct{})
+	for _, file := range zr.File {
+		if !strings.HasSuffix(strings.ToLower(file.Name), ".osu") {
+			continue
+		}
+		r, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		hash, err := md5Hex(r)
+		r.Close()
+		if err != nil {
+			return nil, err
+		}
+		hashes[hash] = struct{}{}
+	}
+	return hashes, nil
+}
+
+func md5Hex(r io.Reader) (string, error) {
+	h := md5.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
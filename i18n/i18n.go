@@ -0,0 +1,116 @@
+// Package i18n translates user-facing messages.
+//
+// Messages are looked up by their English text, so the English catalog is the
+// source code itself and only other languages need a catalog. Errors created
+// with Errorf are rendered in the current language when printed and still
+// match their sentinel with errors.Is.
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// Lang is a supported message language.
+type Lang string
+
+const (
+	English Lang = "en"
+	Chinese Lang = "zh"
+)
+
+var current atomic.Value
+
+func init() {
+	current.Store(English)
+}
+
+// catalogs maps a language to its translations, keyed by the English text.
+var catalogs = map[Lang]map[string]string{
+	Chinese: zh,
+}
+
+// SetLang changes the language of all messages rendered from now on.
+func SetLang(l Lang) {
+	current.Store(l)
+}
+
+// Current returns the active language.
+func Current() Lang {
+	return current.Load().(Lang)
+}
+
+// ParseLang understands language codes and locale names such as "zh",
+// "zh_CN.UTF-8", "zh-Hans" or "en_US".
+func ParseLang(s string) (Lang, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch {
+	case s == "":
+		return "", false
+	case strings.HasPrefix(s, "zh"):
+		return Chinese, true
+	case strings.HasPrefix(s, "en"), s == "c", s == "posix", strings.HasPrefix(s, "c."):
+		return English, true
+	default:
+		return "", false
+	}
+}
+
+// Detect returns the first recognised language among candidates, then the
+// LC_ALL, LC_MESSAGES and LANG environment variables, defaulting to English.
+func Detect(candidates ...string) Lang {
+	for _, env := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		candidates = append(candidates, os.Getenv(env))
+	}
+	for _, c := range candidates {
+		if l, ok := ParseLang(c); ok {
+			return l
+		}
+	}
+	return English
+}
+
+// T translates msg into the current language and formats it with args.
+func T(msg string, args ...any) string {
+	if translated, ok := catalogs[Current()][msg]; ok {
+		msg = translated
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// Error is a translatable error. Its message is rendered on every call to
+// Error, so it follows later SetLang calls.
+type Error struct {
+	Sentinel error
+	Msg      string
+	Args     []any
+}
+
+// Errorf returns an error matching sentinel (which may be nil) whose message
+// is msg translated and formatted with args. Error arguments are unwrapped
+// too, so errors.Is and errors.As see through them.
+func Errorf(sentinel error, msg string, args ...any) error {
+	return &Error{Sentinel: sentinel, Msg: msg, Args: args}
+}
+
+func (e *Error) Error() string {
+	return T(e.Msg, e.Args...)
+}
+
+func (e *Error) Unwrap() []error {
+	var errs []error
+	if e.Sentinel != nil {
+		errs = append(errs, e.Sentinel)
+	}
+	for _, arg := range e.Args {
+		if err, ok := arg.(error); ok {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
@@ -0,0 +1,341 @@
+package i18n
+
+// zh is the Simplified Chinese catalog, keyed by the English message.
+var zh = map[string]string{
+	// cli
+	"List collection beatmaps that are not in osu!.db": "列出osu!.db中没有的收藏夹谱面",
+	"Download missing beatmaps (default command)":      "下载缺失的谱面(默认命令)",
+	"Export collections and their hashes":              "导出收藏夹及其哈希",
+	"Show beatmap and collection counts":               "显示谱面和收藏夹数量",
+	"Show the effective configuration":                 "显示生效的配置",
+	"Check the installation and configuration":         "检查安装和配置",
+	"Error: %s\n":        "错误: %s\n",
+	"missing subcommand": "缺少子命令",
+	"unknown command %q": "未知的命令 %q",
+	"Usage: %s <command> [flags]\n\nCommands:\n":                        "用法: %s <命令> [参数]\n\n命令:\n",
+	"\nRun '%s <command> -h' for the flags of a command.\n":             "\n运行 '%s <命令> -h' 查看命令的参数。\n",
+	"osu! installation directory (overrides config)":                    "osu!安装目录(覆盖配置)",
+	"Songs directory (default <osu>/Songs)":                             "Songs目录(默认 <osu>/Songs)",
+	"Config file to use":                                                "使用的配置文件",
+	"Named osu! installation from the config file":                      "配置文件中命名的osu!安装(profile)",
+	"Output format: text or json":                                       "输出格式: text 或 json",
+	"Message language: en or zh (default from config or $LANG)":         "界面语言: en 或 zh(默认取自配置或 $LANG)",
+	"Concurrent download workers (default %d)":                          "并发下载数(默认 %d)",
+	"Delay between downloads in seconds (default %v)":                   "两次下载之间的间隔秒数(默认 %v)",
+	"Download type: full, novideo or mini":                              "下载类型: full、novideo 或 mini",
+	"HTTP proxy for downloads and API requests":                         "下载和API请求使用的HTTP代理",
+	"Download mirror, tried in order (repeatable)":                      "下载镜像，按顺序尝试(可重复)",
+	"Timeout of a single download (default %v)":                         "单次下载超时(默认 %v)",
+	"Timeout of a single API request (default %v)":                      "单次API请求超时(默认 %v)",
+	"invalid --format %q (want text or json)":                           "无效的 --format %q (可选 text, json)",
+	"invalid --lang %q (want en or zh)":                                 "无效的 --lang %q (可选 en, zh)",
+	"List collections with their beatmap counts":                        "列出收藏夹及其谱面数量",
+	"Show the beatmaps of a collection":                                 "显示收藏夹中的谱面",
+	"%-40s %5d beatmaps, %5d missing\n":                                 "%-40s %5d 个谱面, 缺失 %5d 个\n",
+	"Usage: collections inspect [flags] <name>":                         "用法: collections inspect [参数] <名称>",
+	"expected exactly one collection name":                              "需要且只需要一个收藏夹名称",
+	"collection %q not found":                                           "找不到收藏夹 %q",
+	"%s (%d beatmaps)\n":                                                "%s (%d 个谱面)\n",
+	"installed":                                                         "已安装",
+	"missing":                                                           "缺失",
+	"Print the effective configuration":                                 "打印生效的配置",
+	"Detect the osu! installation and write a config file":              "检测osu!安装路径并写入配置文件",
+	"Validate the configuration, API token, proxy and mirrors":          "验证配置、API令牌、代理和镜像",
+	"# active profile: %s\n":                                            "# 当前profile: %s\n",
+	"Where to write the config file":                                    "配置文件的写入位置",
+	"Overwrite an existing config file":                                 "覆盖已存在的配置文件",
+	"Detected osu! at %s\n":                                             "检测到osu!: %s\n",
+	"osu! installation directory":                                       "osu!安装目录",
+	"A legacy osu! API key is needed to resolve missing beatmaps:":      "查找缺失的谱面需要osu! API v1 密钥:",
+	"osu! website -> Account Settings -> OAuth -> Legacy API":           "osu!官网 -> 账户设置 -> OAuth -> Legacy API",
+	"osu! API token":                                                    "osu! API令牌",
+	"Default download type (full/novideo/mini, empty to ask every run)": "默认下载类型(full/novideo/mini，留空则每次询问)",
+	"could not detect the osu! installation, pass --osu":                "无法检测到osu!安装路径，请使用 --osu 指定",
+	"invalid configuration:\n%v":                                        "配置无效:\n%v",
+	"Config written to %s\n":                                            "配置已写入 %s\n",
+	"No API token set yet: edit osu_api_token before downloading.":      "尚未设置API令牌: 下载前请编辑 osu_api_token。",
+	"config":                       "配置",
+	"valid":                        "有效",
+	"songs folder":                 "Songs目录",
+	"%s is not a directory":        "%s 不是目录",
+	"proxy":                        "代理",
+	"accepted":                     "已通过",
+	"mirror":                       "镜像",
+	"osu! path":                    "osu!路径",
+	"%d beatmaps":                  "%d 个谱面",
+	"%d collections":               "%d 个收藏夹",
+	"Songs folder":                 "Songs目录",
+	"no osu! API token configured": "未配置osu! API令牌",
+	"configured":                   "已配置",
+	"download type":                "下载类型",
+	"%d of %d checks failed":       "%d/%d 项检查未通过",
+	"Sync the collections of this profile into the current one":                                      "把该profile的收藏夹同步到当前安装",
+	"File to write unresolved hashes to":                                                             "写入未解析哈希的文件",
+	"Resolve missing beatmaps and print the download plan without downloading":                       "解析缺失的谱面并打印下载计划，不进行下载",
+	"Write the download plan as JSON to this file":                                                   "把下载计划以JSON写入该文件",
+	"Download the sets of a plan file instead of reading the databases":                              "下载计划文件中的谱面集，不读取数据库",
+	"invalid configuration (run 'config check' for details):\n%v":                                    "配置无效(运行 'config check' 查看详情):\n%v",
+	"Found your osu! at %s.\n\n":                                                                     "找到osu!: %s\n\n",
+	"failed to load plan: %v":                                                                        "加载下载计划失败: %v",
+	"Downloading %d beatmapsets from %s...\n\n":                                                      "正在下载 %[2]s 中的 %[1]d 个谱面集...\n\n",
+	"error downloading beatmaps: %v":                                                                 "下载谱面出错: %v",
+	"Plan finished.\n":                                                                               "下载计划已完成。\n",
+	"Starting to load beatmaps from your osu!.db\n":                                                  "开始从osu!.db加载谱面\n",
+	"Loaded %d beatmaps from osu!.db\n":                                                              "从osu!.db加载了 %d 个谱面\n",
+	"Loaded %d beatmaps from collection.db\n":                                                        "从collection.db加载了 %d 个谱面\n",
+	"No missing beatmaps found!\n":                                                                   "没有缺失的谱面!\n",
+	"Found %d missing beatmaps.\n":                                                                   "发现 %d 个缺失的谱面。\n",
+	"Resolving beatmapsets...\n\n":                                                                   "正在解析谱面集...\n\n",
+	"failed to write plan: %v":                                                                       "写入下载计划失败: %v",
+	"Plan written to %s\n":                                                                           "下载计划已写入 %s\n",
+	"All missing beatmaps downloaded successfully!\n":                                                "所有缺失的谱面均已下载完成!\n",
+	"Starting the downloader...\n\n":                                                                 "开始下载...\n\n",
+	"\nThe %d missing beatmaps in your collection are from %d beatmapsets.\n":                        "\n收藏夹中缺失的 %d 个谱面来自 %d 个谱面集。\n",
+	"%d of %d beatmapsets failed to download":                                                        "%d/%d 个谱面集下载失败",
+	"no download type configured: pass --type full|novideo|mini or set download_type in config.yaml": "未配置下载类型: 请使用 --type full|novideo|mini 或在config.yaml中设置 download_type",
+	"Mirrors: %s\nType:    %s\n\n":                                                                   "镜像: %s\n类型: %s\n\n",
+	"Set %d\n":                                                                                       "谱面集 %d\n",
+	"Unresolved %s (%s)  %s\n":                                                                       "未解析 %s (%s)  %s\n",
+	"\n%d beatmapsets would be downloaded, %d beatmaps are unresolved.\n":                            "\n将下载 %d 个谱面集，%d 个谱面未能解析。\n",
+	"failed to load config: %v":                                                                      "加载配置失败: %v",
+	"could not find the osu! installation: set osu_path in config.yaml or pass --osu":                "无法找到osu!安装路径: 请在config.yaml中设置 osu_path 或使用 --osu",
+	"could not find osu! path: %s":                                                                   "找不到osu!路径: %s",
+	"failed to read osu!.db: %v":                                                                     "读取osu!.db失败: %v",
+	"failed to read collection.db: %v":                                                               "读取collection.db失败: %v",
+	"failed to read collection.db of profile %s: %v":                                                 "读取profile %s 的collection.db失败: %v",
+	"Only export this collection (repeatable)":                                                       "只导出该收藏夹(可重复)",
+	"Exported %d collections to %s\n":                                                                "已导出 %d 个收藏夹到 %s\n",
+	"Log level: debug, info, warn or error":                                                          "日志级别: debug、info、warn 或 error",
+	"Log format: text or json":                                                                       "日志格式: text 或 json",
+	"Append logs to this file instead of stderr":                                                     "把日志追加到该文件而不是标准错误",
+	"invalid --log-level %q":                                                                         "无效的 --log-level %q",
+	"failed to open log file: %v":                                                                    "打开日志文件失败: %v",
+	"invalid --log-format %q (want text or json)":                                                    "无效的 --log-format %q (可选 text, json)",
+	"\n%d missing beatmaps.\n":                                                                       "\n缺失 %d 个谱面。\n",
+	"Failed to write unresolved report: %v\n":                                                        "写入未解析报告失败: %v\n",
+	"Unresolved beatmaps were written to %s\n":                                                       "未解析的谱面已写入 %s\n",
+	"\n%d beatmaps could not be resolved to a beatmapset:\n":                                         "\n%d 个谱面无法解析到谱面集:\n",
+	"Beatmaps in osu!.db:          %d\n":                                                             "osu!.db中的谱面:     %d\n",
+	"Collections:                  %d\n":                                                             "收藏夹:              %d\n",
+	"Beatmaps in collections:      %d\n":                                                             "收藏夹中的谱面:      %d\n",
+	"Missing collection beatmaps:  %d\n":                                                             "缺失的收藏夹谱面:    %d\n",
+	"Warning: osu! is running and may overwrite changes to its databases when it exits.\n":           "警告: osu!正在运行，退出时可能会覆盖对其数据库的修改。\n",
+	"List, inspect and edit collections in collection.db":                                            "列出、查看和编辑collection.db中的收藏夹",
+	"Create an empty collection":                                                                     "新建空收藏夹",
+	"Rename a collection":                                                                            "重命名收藏夹",
+	"Delete a collection":                                                                            "删除收藏夹",
+	"Add beatmaps by hash or beatmap ID to a collection":                                             "按哈希或谱面ID向收藏夹添加谱面",
+	"Remove beatmaps by hash or beatmap ID from a collection":                                        "按哈希或谱面ID从收藏夹移除谱面",
+	"Usage: %s [flags] %s":                                                                           "用法: %s [参数] %s",
+	"wrong number of arguments":                                                                      "参数数量错误",
+	"%q is neither a beatmap hash nor a beatmap ID":                                                  "%q 既不是谱面哈希也不是谱面ID",
+	"beatmap %d: %v":                                                                                 "谱面 %d: %v",
+	"Backup written to %s\n":                                                                         "备份已写入 %s\n",
+	"Created collection %q\n":                                                                        "已新建收藏夹 %q\n",
+	"Renamed collection %q to %q\n":                                                                  "已将收藏夹 %q 重命名为 %q\n",
+	"Deleted collection %q\n":                                                                        "已删除收藏夹 %q\n",
+	"Added %d beatmaps to %q\n":                                                                      "已向 %[2]q 添加 %[1]d 个谱面\n",
+	"Removed %d beatmaps from %q\n":                                                                  "已从 %[2]q 移除 %[1]d 个谱面\n",
+	"Merge other collection.db files into one":                                                       "把其他collection.db文件合并为一个",
+	"Same-named collections: union, keep-ours, keep-theirs or rename-with-suffix":                    "同名收藏夹的处理方式: union、keep-ours、keep-theirs 或 rename-with-suffix",
+	"Merge into this collection.db instead of the installation's":                                    "合并到该collection.db，而不是当前安装的",
+	"Write the result to this file instead of the installation's collection.db":                      "把结果写入该文件，而不是当前安装的collection.db",
+	"Only show the changes":                                                                          "只显示改动",
+	"Write without asking for confirmation":                                                          "不经确认直接写入",
+	"Write the merged collections to %s?":                                                            "把合并后的收藏夹写入 %s?",
+	"No changes.":                                                                                    "没有改动。",
+	"+ %s (%d beatmaps)\n":                                                                           "+ %s (%d 个谱面)\n",
+	"- %s (%d beatmaps)\n":                                                                           "- %s (%d 个谱面)\n",
+	"~ %s: %d added, %d removed\n":                                                                   "~ %s: 增加 %d 个, 移除 %d 个\n",
+	"refusing to write without confirmation: pass --yes":                                             "未经确认不会写入: 请使用 --yes",
+	"Nothing was written.\n":                                                                         "未写入任何内容。\n",
+	"Merged collections written to %s\n":                                                             "合并后的收藏夹已写入 %s\n",
+	"Combine the beatmaps of several collections":                                                    "合并多个收藏夹的谱面",
+	"Keep the beatmaps found in every given collection":                                              "只保留所有给定收藏夹中都有的谱面",
+	"Remove the beatmaps of later collections from the first":                                        "从第一个收藏夹中去掉后面收藏夹里的谱面",
+	"Name of the collection to write the result to":                                                  "写入结果的收藏夹名称",
+	"Overwrite the --into collection if it exists":                                                   "--into 指定的收藏夹已存在时覆盖它",
+	"Print the resulting hashes instead of writing a collection":                                     "只输出结果哈希，不写入收藏夹",
+	"pass either --into <name> or --hashes-only":                                                     "请使用 --into <名称> 或 --hashes-only 其中之一",
+	"Wrote %d beatmaps to collection %q\n":                                                           "已向收藏夹 %[2]q 写入 %[1]d 个谱面\n",
+	"Show the differences between two collection.db files":                                           "显示两个collection.db文件之间的差异",
+	"> %s -> %s: %d added, %d removed\n":                                                             "> %s -> %s: 增加 %d 个, 移除 %d 个\n",
+	"\n%d collections added, %d removed, %d renamed, %d changed.\n":                                  "\n新增 %d 个收藏夹，删除 %d 个，改名 %d 个，修改 %d 个。\n",
+	"%-20s %s %10d bytes\n":                                                                          "%-20s %s %10d 字节\n",
+	"Restore backup %s to %s?":                                                                       "把备份 %s 恢复到 %s？",
+	"No backups in %s.\n":                                                                            "%s 中没有备份。\n",
+	"Cannot read the current collection.db, the whole backup is shown: %v\n":                         "无法读取当前的collection.db，下面列出备份的全部内容: %v\n",
+	"Restored backup %s to %s\n":                                                                     "已把备份 %s 恢复到 %s\n",
+	"List the backups of collection.db, newest first":                                                "列出collection.db的备份，最新的在前",
+	"Restore collection.db from a backup":                                                            "从备份恢复collection.db",
+	"List or restore backups of collection.db":                                                       "列出或恢复collection.db的备份",
+	"Loaded %d beatmaps from %s\n":                                                                   "从%[2]s加载了 %[1]d 个谱面\n",
+	"%d beatmaps have their set ID in %s and are not looked up.\n":                                   "%[2]s 中已有 %[1]d 个谱面的谱面集ID，无需查询。\n",
+	"--from and --from-profile cannot be used together":                                              "--from 和 --from-profile 不能同时使用",
+	"Write to this file instead of stdout; a .osdb file is written in Collection Manager format":                            "写入该文件而不是标准输出；.osdb文件以Collection Manager格式写入",
+	"Import collections from other sources":                                                                                 "从其他来源导入收藏夹",
+	"Downloading %d beatmapsets...\n\n":                                                                                     "正在下载 %d 个谱面集...\n\n",
+	"Write the beatmaps into collection.db":                                                                                 "把谱面写入collection.db",
+	"Name of the collection in collection.db (default: the osu!collector name)":                                             "写入collection.db时的收藏夹名称(默认使用osu!collector上的名称)",
+	"Overwrite the collection if it exists":                                                                                 "收藏夹已存在时覆盖它",
+	"Download the beatmapsets that are not installed":                                                                       "下载未安装的谱面集",
+	"%q is neither an osu!collector collection ID nor a link to one":                                                        "%q 既不是osu!collector收藏夹ID，也不是收藏夹链接",
+	"failed to fetch osu!collector collection %d: %v":                                                                       "获取osu!collector收藏夹 %d 失败: %v",
+	"Fetched %q from osu!collector: %d beatmaps in %d beatmapsets\n":                                                        "已从osu!collector获取 %q: %d 个谱面，共 %d 个谱面集\n",
+	"Import a collection from osu!collector":                                                                                "从osu!collector导入收藏夹",
+	"Sync the collections of this collection.db or .osdb file, or download the beatmaps of a list of links, IDs and hashes": "同步该collection.db或.osdb文件中的收藏夹，或下载列表文件中的链接、ID和哈希对应的谱面",
+	"What bare numbers in a --from list are: set or beatmap IDs":                                                            "--from 列表中单独的数字的含义: set(谱面集ID)或beatmap(谱面ID)",
+	"invalid --ids %q (want set or beatmap)":                                                                                "无效的 --ids %q (应为 set 或 beatmap)",
+	"no beatmaps found in %s":                                                                                               "%s 中没有找到谱面",
+	"%d entries could not be resolved":                                                                                      "%d 项无法解析",
+	"Ignoring unrecognized line: %s\n":                                                                                      "忽略无法识别的行: %s\n",
+	"Could not resolve %s (line %d): %v\n":                                                                                  "无法解析 %s (第 %d 行): %v\n",
+	"%d entries: %d beatmapsets to download, %d already installed, %d unresolved.\n":                                        "共 %d 项: 需下载 %d 个谱面集，%d 个已安装，%d 个无法解析。\n",
+	"List the missing beatmaps of this collection.db or .osdb file":                                                         "列出该collection.db或.osdb文件中缺失的谱面",
+	"--from needs a collection.db or .osdb file":                                                                            "--from 需要collection.db或.osdb文件",
+	"Create collections from a tournament mappool CSV":                                                                      "根据比赛图池CSV创建收藏夹",
+	"Name of the collection, or the prefix of the per-bracket collections":                                                  "收藏夹名称，或按Mod分组时各收藏夹名称的前缀",
+	"Overwrite the collections if they exist":                                                                               "收藏夹已存在时覆盖它们",
+	"Do not download missing beatmapsets":                                                                                   "不下载缺失的谱面集",
+	"Only show the resolved slots":                                                                                          "只显示解析后的图池位置",
+	"--name is required":                                                                                                    "必须指定 --name",
+	"%d of %d slots could not be resolved":                                                                                  "%[2]d 个位置中有 %[1]d 个无法解析",
+	"Could not resolve %s (beatmap %d): %v\n":                                                                               "无法解析 %s (谱面 %d): %v\n",
+	"Create one collection per mod bracket, named like <name> NM":                                                           "每个Mod分组创建一个收藏夹，名称如 <名称> NM",
+	"Print a share code for a collection":                                                                                   "输出收藏夹的分享码",
+	"Add a collection from a share code":                                                                                    "从分享码添加收藏夹",
+	"Save under this name instead of the shared one":                                                                        "使用此名称保存，而不是分享码中的名称",
+	"the share code has no collection name, use --name":                                                                     "分享码中没有收藏夹名称，请使用 --name",
+	"Share code for %q (%d beatmaps):\n":                                                                                    "收藏夹 %q 的分享码(%d 张谱面)：\n",
+	"Imported %d beatmaps into collection %q\n":                                                                             "已导入 %d 张谱面到收藏夹 %q\n",
+	"Read the osu! API token from this file instead of storing it":                                                          "从该文件读取 osu! API 令牌，而不是写入配置",
+	"Skip rows whose beatmap cannot be read instead of failing":                                                             "跳过无法读取谱面的行，而不是报错",
+	"Row %s (line %d): %q %s\n":                                                                                             "位置 %s (第 %d 行): %q %s\n",
+	"not a beatmap ID or difficulty link":                                                                                   "不是谱面ID或难度链接",
+	"links to a beatmapset, not a difficulty":                                                                               "链接的是谱面集而不是难度",
+	"%d rows could not be read; fix them or pass --skip-invalid":                                                            "%d 行无法读取；请修正或使用 --skip-invalid",
+
+	// config
+	"environment variable %s: %v": "环境变量 %s: %v",
+	"command line flag %s: %v":    "命令行参数 %s: %v",
+	"cannot find the osu! installation, please set osu_path in the config file": "无法找到osu!安装路径,请在配置文件中指定",
+	"cannot load the config file: %v":                                           "无法加载配置文件: %v",
+	"failed to parse config file %s: %v":                                        "解析配置文件%s失败: %v",
+	"unknown config key":                                                        "未知的配置项",
+	"invalid value %q":                                                          "无效的值 %q",
+	"osu_path must not be empty":                                                "osu_path 不能为空",
+	"invalid osu! path: %s":                                                     "无效的osu!路径: %s",
+	"invalid download_type: %q (want full, novideo or mini)":                    "无效的 download_type: %q (可选 full, novideo, mini)",
+	"profile %s: invalid download_type: %q":                                     "profile %s: 无效的 download_type: %q",
+	"default_profile does not exist: %s":                                        "default_profile 不存在: %s",
+	"workers must be greater than 0: %d":                                        "workers 必须大于0: %d",
+	"delay must not be negative: %v":                                            "delay 不能为负数: %v",
+	"timeout and api_timeout must be greater than 0":                            "timeout 和 api_timeout 必须大于0",
+	"invalid lang: %q (want en or zh)":                                          "无效的 lang: %q (可选 en, zh)",
+	"invalid proxy URL: %s":                                                     "无效的代理地址: %s",
+	"mirrors must not be empty":                                                 "mirrors 不能为空",
+	"invalid mirror URL: %s":                                                    "无效的镜像地址: %s",
+	"cannot open %s: %v":                                                        "打开%s失败: %v",
+	"failed to read %s: %v":                                                     "读取%s失败: %v",
+	"unknown profile: %s (the config file defines no profiles)":                 "未知的profile: %s (配置文件中没有定义profiles)",
+	"unknown profile: %s (available: %s)":                                       "未知的profile: %s (可选: %s)",
+	"failed to read token_file: %v":                                             "读取 token_file 失败: %v",
+	"failed to create the config directory: %v":                                 "创建配置目录失败: %v",
+	"config file already exists: %s":                                            "配置文件已存在: %s",
+	"failed to write the config file: %v":                                       "写入配置文件失败: %v",
+	"backup_retention must not be negative: %d":                                 "backup_retention 不能为负数: %d",
+	"invalid osucollector_url: %s":                                              "无效的osucollector_url: %s",
+
+	// db
+	"%s changed while it was being read":                "%s在读取期间被修改",
+	"failed to create a snapshot of %s: %v":             "创建%s的快照失败: %v",
+	"cannot open collection.db: %v":                     "打开collection.db失败: %v",
+	"failed to read the version: %v":                    "读取版本号失败: %v",
+	"failed to read the collection count: %v":           "读取收藏夹数量失败: %v",
+	"failed to read collection %d: %v":                  "读取第%d个收藏夹失败: %v",
+	"failed to read the collection name: %v":            "读取收藏夹名称失败: %v",
+	"failed to read the beatmap count: %v":              "读取谱面数量失败: %v",
+	"failed to read hash %d: %v":                        "读取第%d个哈希失败: %v",
+	"failed to read the string indicator: %v":           "读取字符串标志失败: %v",
+	"failed to read the string length: %v":              "读取字符串长度失败: %v",
+	"string too long: %d":                               "字符串长度过长: %d",
+	"failed to skip the string content: %v":             "跳过字符串内容失败: %v",
+	"incomplete string content, expected length %d: %v": "字符串内容不完整，期望长度 %d: %v",
+	"cannot read the string content: %v":                "无法读取字符串内容: %v",
+	"invalid UTF-8 encoding":                            "无效的UTF-8编码",
+	"invalid string indicator: 0x%02x":                  "无效的字符串标志: 0x%02x",
+	"failed to read ULEB128: %v":                        "读取ULEB128失败: %v",
+	"ULEB128 value too large":                           "ULEB128值过大",
+	"unknown data type: %s":                             "未知数据类型: %s",
+	"failed to read the version-specific integer: %v":   "读取版本特定整数失败: %v",
+	"failed to read beatmap data: %v":                   "读取谱面数据失败: %v",
+	"failed to read the slider velocity: %v":            "读取滑条速度失败: %v",
+	"failed to read the star rating count: %v":          "读取星级评分数量失败: %v",
+	"failed to read a star rating pair: %v":             "读取星级评分对失败: %v",
+	"failed to read timing points: %v":                  "读取节奏点失败: %v",
+	"failed to read extra beatmap data: %v":             "读取额外谱面数据失败: %v",
+	"cannot open osu!.db: %v":                           "打开文件失败: %v",
+	"failed to read the file header: %v":                "读取文件头数据失败: %v",
+	"failed to parse a beatmap: %v":                     "解析谱面失败: %v",
+	"failed to read the osu!.db version: %v":            "读取osuDB版本失败: %v",
+	"the collection name must not be empty":             "收藏夹名称不能为空",
+	"collection %q already exists":                      "收藏夹 %q 已存在",
+	"failed to write %s: %v":                            "写入%s失败: %v",
+	"failed to back up %s: %v":                          "备份%s失败: %v",
+	"invalid merge strategy %q (want union, keep-ours, keep-theirs or rename-with-suffix)": "无效的合并策略 %q (可选 union, keep-ours, keep-theirs, rename-with-suffix)",
+	"backup %s not found":                      "找不到备份 %s",
+	"invalid collection count: %d":             "无效的收藏夹数量: %d",
+	"invalid beatmap count: %d":                "无效的谱面数量: %d",
+	"failed to read the .osdb version: %v":     "读取.osdb版本失败: %v",
+	"unsupported .osdb version %q":             "不支持的.osdb版本 %q",
+	"failed to decompress the .osdb file: %v":  "解压.osdb文件失败: %v",
+	"the .osdb version %q does not match %q":   ".osdb版本 %q 与 %q 不一致",
+	"failed to read the .osdb collections: %v": "读取.osdb中的收藏夹失败: %v",
+	"the .osdb file has no valid footer":       ".osdb文件缺少有效的结尾标记",
+	"invalid beatmap hash %q":                  "无效的谱面哈希 %q",
+	"share codes start with %s":                "分享码应以 %s 开头",
+	"the share code is incomplete or damaged":  "分享码不完整或已损坏",
+
+	// downloader
+	"no token":                         "没有令牌",
+	"not found":                        "未找到",
+	"http error":                       "HTTP错误",
+	"decode error":                     "解析错误",
+	"osu! API request failed: %v":      "osu! API请求失败: %v",
+	"osu! API request failed: HTTP %d": "osu! API请求失败: HTTP %d",
+	"invalid osu! API response: %v":    "无效的osu! API响应: %v",
+	"beatmap not found":                "找不到谱面",
+	"invalid osu! API response: beatmapset_id %q":           "无效的osu! API响应: beatmapset_id %q",
+	"the osu! API token was rejected":                       "osu! API令牌被拒绝",
+	"invalid download type %q (want full, novideo or mini)": "无效的下载类型 %q (可选 full, novideo, mini)",
+	"failed to create directory: %v":                        "创建目录失败: %v",
+	"failed to scan %s: %v":                                 "扫描%s失败: %v",
+	"request failed: %v":                                    "请求失败: %v",
+	"invalid content type: %s":                              "无效的内容类型: %s",
+	"failed to rename file after multiple attempts: %v":     "多次尝试后仍无法重命名文件: %v",
+	"invalid plan %s: %v":                                   "无效的下载计划 %s: %v",
+	"invalid plan %s: bad type %q":                          "无效的下载计划 %s: 错误的类型 %q",
+	"invalid plan %s: bad set_id %d":                        "无效的下载计划 %s: 错误的 set_id %d",
+	"invalid osu! API response: missing file_md5":           "无效的osu! API响应: 缺少 file_md5",
+	"osu!collector request failed: %v":                      "osu!collector请求失败: %v",
+	"osu!collector collection %d not found":                 "找不到osu!collector收藏夹 %d",
+	"osu!collector request failed: HTTP %d":                 "osu!collector请求失败: HTTP %d",
+	"invalid osu!collector response: %v":                    "无效的osu!collector响应: %v",
+
+	// utils
+	"no input":                                "没有输入",
+	"Please select the download type:":        "请选择下载类型:",
+	"1. with video (full)":                    "1. 带视频 (full)",
+	"2. without video (novideo)":              "2. 不带视频 (novideo)",
+	"3. mini":                                 "3. 精简版 (mini)",
+	"Enter your choice (1/2/3): ":             "请输入选项 (1/2/3): ",
+	"no download type selected":               "未选择下载类型",
+	"Invalid choice, please enter 1, 2 or 3.": "无效的选项，请输入 1、2 或 3。",
+
+	// lock
+	"failed to write lock file %s: %v":                                                    "写入锁文件%s失败: %v",
+	"failed to create lock file %s: %v":                                                   "创建锁文件%s失败: %v",
+	"another instance (PID %d) is already working on %s; if it is not running, delete %s": "另一个实例(PID %d)正在使用%s；如果它并未运行，请删除 %s",
+	"failed to remove stale lock file %s: %v":                                             "删除过期的锁文件%s失败: %v",
+	"another instance is already working on %s":                                           "另一个实例正在使用%s",
+}
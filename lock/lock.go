@@ -0,0 +1,99 @@
+// Package lock keeps two instances from writing to the same osu!
+// installation at once.
+package lock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"OsuCollectionTab/i18n"
+)
+
+// FileName is the lock file created in the locked directory.
+const FileName = ".osu-collection-tab.lock"
+
+// ErrLocked is returned when another running instance holds the lock.
+var ErrLocked = errors.New("locked by another instance")
+
+// Lock is an acquired advisory lock.
+type Lock struct {
+	path string
+}
+
+// Acquire creates the lock file in dir, recording the current PID. A lock
+// left behind by a process that is no longer running is taken over.
+func Acquire(dir string) (*Lock, error) {
+	path := filepath.Join(dir, FileName)
+
+	// The PID is written to a temporary file that is then linked into place,
+	// so that the lock file never exists without it: another instance
+	// reading it in between would take it for a stale lock.
+	tmp, err := writePID(dir)
+	if err != nil {
+		return nil, i18n.Errorf(nil, "failed to write lock file %s: %v", path, err)
+	}
+	defer os.Remove(tmp)
+
+	// The second attempt follows the removal of a stale lock; losing that
+	// race to another instance is reported like any other held lock.
+	for attempt := 0; attempt < 2; attempt++ {
+		err := os.Link(tmp, path)
+		if err == nil {
+			return &Lock{path: path}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, i18n.Errorf(nil, "failed to create lock file %s: %v", path, err)
+		}
+
+		pid, ok := readPID(path)
+		if ok && pid != os.Getpid() && processAlive(pid) {
+			return nil, i18n.Errorf(ErrLocked, "another instance (PID %d) is already working on %s; if it is not running, delete %s", pid, dir, path)
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, i18n.Errorf(nil, "failed to remove stale lock file %s: %v", path, err)
+		}
+	}
+	return nil, i18n.Errorf(ErrLocked, "another instance is already working on %s", dir)
+}
+
+// Release removes the lock file.
+func (l *Lock) Release() error {
+	return os.Remove(l.path)
+}
+
+// writePID writes the current PID to a new temporary file in dir and
+// returns its path.
+func writePID(dir string) (string, error) {
+	f, err := os.CreateTemp(dir, FileName+".*")
+	if err != nil {
+		return "", err
+	}
+	_, err = fmt.Fprintf(f, "%d\n", os.Getpid())
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// readPID returns the PID recorded in a lock file. As the file is always
+// created with its PID, a missing or garbled file reports false and is
+// treated as stale.
+func readPID(path string) (int, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return 0, false
+	}
+	return pid, true
+}
@@ -0,0 +1,58 @@
+package lock
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestAcquire(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string // contents of a lock file left in place, if any
+		want     error
+	}{
+		{"free", "", nil},
+		{"held by a running process", strconv.Itoa(os.Getppid()) + "\n", ErrLocked},
+		{"garbled", "not a pid", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, FileName)
+			if tt.existing != "" {
+				if err := os.WriteFile(path, []byte(tt.existing), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			l, err := Acquire(dir)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Acquire error = %v, want %v", err, tt.want)
+			}
+
+			entries, rerr := os.ReadDir(dir)
+			if rerr != nil {
+				t.Fatal(rerr)
+			}
+			if len(entries) != 1 || entries[0].Name() != FileName {
+				t.Errorf("directory holds %v, want only the lock file", entries)
+			}
+			if err != nil {
+				return
+			}
+
+			if pid, ok := readPID(path); !ok || pid != os.Getpid() {
+				t.Errorf("lock file records PID %d, want %d", pid, os.Getpid())
+			}
+			if err := l.Release(); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("lock file still exists after Release: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !windows
+
+package lock
+
+import (
+	"errors"
+	"syscall"
+)
+
+// processAlive reports whether a process with the given PID exists. Signal 0
+// only checks for existence; EPERM means it exists but belongs to another user.
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,26 @@
+//go:build windows
+
+package lock
+
+import "syscall"
+
+const (
+	processQueryLimitedInformation = 0x1000
+	stillActive                    = 259
+)
+
+// processAlive reports whether a process with the given PID is still running.
+func processAlive(pid int) bool {
+	h, err := syscall.OpenProcess(processQueryLimitedInformation, false, uint32(pid))
+	if err != nil {
+		// Access denied still proves the process exists.
+		return err == syscall.ERROR_ACCESS_DENIED
+	}
+	defer syscall.CloseHandle(h)
+
+	var code uint32
+	if err := syscall.GetExitCodeProcess(h, &code); err != nil {
+		return false
+	}
+	return code == stillActive
+}
@@ -0,0 +1,77 @@
+// Package secret keeps API tokens out of logs, reports and error messages.
+package secret
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Mask replaces every redacted secret.
+const Mask = "***"
+
+// minLength keeps very short values (e.g. "1") from masking unrelated text.
+const minLength = 4
+
+var (
+	mu      sync.RWMutex
+	secrets []string
+
+	// queryKey matches the legacy API key parameter even for unregistered tokens.
+	queryKey = regexp.MustCompile(`([?&]k=)[^&\s"']+`)
+)
+
+// Register marks s as a secret to be redacted from now on.
+func Register(s string) {
+	s = strings.TrimSpace(s)
+	if len(s) < minLength {
+		return
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, known := range secrets {
+		if known == s {
+			return
+		}
+	}
+	secrets = append(secrets, s)
+	// Longer secrets first so that a secret containing another is fully masked.
+	for i := len(secrets) - 1; i > 0 && len(secrets[i]) > len(secrets[i-1]); i-- {
+		secrets[i], secrets[i-1] = secrets[i-1], secrets[i]
+	}
+}
+
+// Redact masks every registered secret and API key query parameter in s.
+func Redact(s string) string {
+	mu.RLock()
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, Mask)
+		if escaped := url.QueryEscape(secret); escaped != secret {
+			s = strings.ReplaceAll(s, escaped, Mask)
+		}
+	}
+	mu.RUnlock()
+
+	return queryKey.ReplaceAllString(s, "${1}"+Mask)
+}
+
+// Error wraps err so that its message is redacted. errors.Is and errors.As
+// still see the original chain, so sentinel checks keep working.
+func Error(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*redacted); ok {
+		return err
+	}
+	return &redacted{err: err}
+}
+
+type redacted struct {
+	err error
+}
+
+func (r *redacted) Error() string { return Redact(r.err.Error()) }
+func (r *redacted) Unwrap() error { return r.err }
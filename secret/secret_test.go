@@ -0,0 +1,56 @@
+package secret
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	Register("s3cr3t-token+/=")
+	Register("abcd")
+	Register("abcdefgh")
+	Register("xyz") // too short to be registered
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain", "token s3cr3t-token+/= leaked", "token *** leaked"},
+		{"query escaped", "/api?h=1&x=s3cr3t-token%2B%2F%3D", "/api?h=1&x=***"},
+		{"longer secret first", "abcdefgh abcd", "*** ***"},
+		{"short value kept", "xyz", "xyz"},
+		{"unregistered k parameter", "/api/get_beatmaps?h=1&k=unknownkey&limit=1", "/api/get_beatmaps?h=1&k=***&limit=1"},
+		{"k parameter first", "/api?k=unknownkey", "/api?k=***"},
+		{"k parameter in quotes", `Get "http://host/api?k=unknownkey": EOF`, `Get "http://host/api?k=***": EOF`},
+		{"other parameters kept", "/api?kk=1&sk=2", "/api?kk=1&sk=2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Redact(tt.in); got != tt.want {
+				t.Errorf("Redact(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestError(t *testing.T) {
+	Register("t0ken-for-errors")
+	sentinel := errors.New("sentinel")
+	err := Error(fmt.Errorf("%w: GET /api?k=t0ken-for-errors", sentinel))
+
+	if strings.Contains(err.Error(), "t0ken-for-errors") {
+		t.Errorf("Error() = %q contains the token", err.Error())
+	}
+	if !errors.Is(err, sentinel) {
+		t.Error("errors.Is does not see the wrapped sentinel")
+	}
+	if Error(err) != err {
+		t.Error("a redacted error is wrapped again")
+	}
+	if Error(nil) != nil {
+		t.Error("Error(nil) is not nil")
+	}
+}
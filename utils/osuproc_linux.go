@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// OsuRunning reports whether an osu! process is running. Under Wine the
+// process shows up in /proc with osu!.exe as its name or first argument.
+func OsuRunning() (bool, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return false, err
+	}
+
+	self := os.Getpid()
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || pid == self {
+			continue
+		}
+		dir := filepath.Join("/proc", entry.Name())
+
+		if comm, err := os.ReadFile(filepath.Join(dir, "comm")); err == nil && isOsuExe(strings.TrimSpace(string(comm))) {
+			return true, nil
+		}
+		if cmdline, err := os.ReadFile(filepath.Join(dir, "cmdline")); err == nil {
+			argv0, _, _ := strings.Cut(string(cmdline), "\x00")
+			if isOsuExe(argv0) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
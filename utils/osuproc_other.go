@@ -0,0 +1,9 @@
+//go:build !linux && !windows
+
+package utils
+
+// OsuRunning reports whether an osu! process is running. Detection is not
+// supported on this platform, so it always reports false.
+func OsuRunning() (bool, error) {
+	return false, nil
+}
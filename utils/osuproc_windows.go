@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// OsuRunning reports whether an osu! process is running.
+func OsuRunning() (bool, error) {
+	snapshot, err := syscall.CreateToolhelp32Snapshot(syscall.TH32CS_SNAPPROCESS, 0)
+	if err != nil {
+		return false, err
+	}
+	defer syscall.CloseHandle(snapshot)
+
+	var entry syscall.ProcessEntry32
+	entry.Size = uint32(unsafe.Sizeof(entry))
+	for err = syscall.Process32First(snapshot, &entry); err == nil; err = syscall.Process32Next(snapshot, &entry) {
+		if isOsuExe(syscall.UTF16ToString(entry.ExeFile[:])) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
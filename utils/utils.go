@@ -1,9 +1,14 @@
 package utils
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
+
+	"OsuCollectionTab/i18n"
 )
 
 func PathExists(path string) bool {
@@ -11,25 +16,124 @@ func PathExists(path string) bool {
 	return !os.IsNotExist(err)
 }
 
-func PromptDownloadType() string {
-	fmt.Println("Please select the download type:")
-	fmt.Println("1. with video (full)")
-	fmt.Println("2. without video (novideo)")
-	fmt.Println("3. mini")
-
-	var choice string
-	fmt.Print("Enter your choice (1/2/3): ")
-	fmt.Scanln(&choice)
-
-	switch strings.TrimSpace(choice) {
-	case "1":
-		return "full"
-	case "2":
-		return "novideo"
-	case "3":
-		return "mini"
-	default:
-		fmt.Println("Invalid choice, defaulting to 'full'")
-		return "full"
-	}
-}
\ No newline at end of file
+// IsTerminal reports whether f is an interactive character device.
+// The null device is a character device too, so it is excluded explicitly.
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	if null, err := os.Stat(os.DevNull); err == nil && os.SameFile(info, null) {
+		return false
+	}
+	return true
+}
+
+// ErrNoInput is returned when stdin ends before a prompt is answered.
+var ErrNoInput = errors.New("no input")
+
+// stdin is shared by all prompts so that buffered input is not lost between them.
+var stdin = bufio.NewScanner(os.Stdin)
+
+// Prompt asks for a line on stdin and returns def when the answer is empty.
+func Prompt(label, def string) (string, error) {
+	if def != "" {
+		fmt.Printf("%s [%s]: ", label, def)
+	} else {
+		fmt.Printf("%s: ", label)
+	}
+
+	if !stdin.Scan() {
+		if err := stdin.Err(); err != nil {
+			return "", err
+		}
+		return "", i18n.Errorf(ErrNoInput, "no input")
+	}
+	if answer := strings.TrimSpace(stdin.Text()); answer != "" {
+		return answer, nil
+	}
+	return def, nil
+}
+
+// Confirm asks a yes/no question on stdin; anything but y or yes means no.
+func Confirm(label string) (bool, error) {
+	answer, err := Prompt(label+" [y/N]", "")
+	if err != nil {
+		return false, err
+	}
+	switch strings.ToLower(answer) {
+	case "y", "yes", "是":
+		return true, nil
+	}
+	return false, nil
+}
+
+// PromptDownloadType asks on stdin until a valid choice is entered and
+// returns "full", "novideo" or "mini".
+func PromptDownloadType() (string, error) {
+	fmt.Println(i18n.T("Please select the download type:"))
+	fmt.Println(i18n.T("1. with video (full)"))
+	fmt.Println(i18n.T("2. without video (novideo)"))
+	fmt.Println(i18n.T("3. mini"))
+
+	for {
+		fmt.Print(i18n.T("Enter your choice (1/2/3): "))
+		if !stdin.Scan() {
+			return "", i18n.Errorf(ErrNoInput, "no download type selected")
+		}
+
+		switch strings.TrimSpace(stdin.Text()) {
+		case "1", "full":
+			return "full", nil
+		case "2", "novideo":
+			return "novideo", nil
+		case "3", "mini":
+			return "mini", nil
+		default:
+			fmt.Println(i18n.T("Invalid choice, please enter 1, 2 or 3."))
+		}
+	}
+}
+
+// FindFold returns the path of name inside dir, matching the name
+// case-insensitively when there is no exact match. Useful for osu! files
+// living on case-sensitive file systems (e.g. under Wine).
+func FindFold(dir, name string) (string, bool) {
+	path := filepath.Join(dir, name)
+	if _, err := os.Stat(path); err == nil {
+		return path, true
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return path, false
+	}
+	for _, entry := range entries {
+		if strings.EqualFold(entry.Name(), name) {
+			return filepath.Join(dir, entry.Name()), true
+		}
+	}
+	return path, false
+}
+
+// JoinFold joins elem onto dir like filepath.Join, resolving every element
+// with FindFold. The second result reports whether the full path exists.
+func JoinFold(dir string, elem ...string) (string, bool) {
+	path := dir
+	for _, e := range elem {
+		var ok bool
+		if path, ok = FindFold(path, e); !ok {
+			return filepath.Join(append([]string{dir}, elem...)...), false
+		}
+	}
+	return path, true
+}
+
+// isOsuExe reports whether a process name or path refers to osu!.exe, with
+// either kind of path separator.
+func isOsuExe(name string) bool {
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+	return strings.EqualFold(name, "osu!.exe")
+}